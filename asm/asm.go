@@ -0,0 +1,282 @@
+// Package asm assembles chip8 source code into roms,
+// the mnemonics are the ones printed by the disasm package
+package asm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// origin is the address where the roms are loaded
+const origin = 0x200
+
+// Error is an assembly error at a position of a source file
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// statement is a source line, it defines a label, a constant,
+// or it is an instruction or a data directive
+type statement struct {
+	file     string
+	line     int
+	col      int
+	label    string
+	constant string
+	name     string
+	operands [][]token
+	address  int
+	size     int
+}
+
+// errorf returns an error at a column of the statement
+func (st *statement) errorf(col int, format string, args ...interface{}) *Error {
+	return &Error{File: st.file, Line: st.line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// assembler holds the statements of a program and its symbols
+type assembler struct {
+	statements []*statement
+	symbols    map[string]int
+	including  map[string]bool
+}
+
+// AssembleFile assembles a source file into a rom to load at 0x200,
+// the included files are relative to the file including them
+func AssembleFile(path string) ([]byte, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Assemble(path, src)
+}
+
+// Assemble assembles source code into a rom to load at 0x200,
+// name is the file reported in the errors and locates the includes
+func Assemble(name string, src []byte) ([]byte, error) {
+	a := &assembler{symbols: make(map[string]int), including: make(map[string]bool)}
+	if err := a.parse(name, src); err != nil {
+		return nil, err
+	}
+	if err := a.layout(); err != nil {
+		return nil, err
+	}
+	rom, err := a.emit()
+	if err != nil {
+		return nil, err
+	}
+	return rom, nil
+}
+
+// parse splits the source in statements, replacing the includes by their statements
+func (a *assembler) parse(name string, src []byte) *Error {
+	a.including[name] = true
+	defer delete(a.including, name)
+	for i, line := range strings.Split(string(src), "\n") {
+		tokens, err := lex(line)
+		if err != nil {
+			err.File = name
+			err.Line = i + 1
+			return err
+		}
+		st := &statement{file: name, line: i + 1}
+		if len(tokens) >= 2 && tokens[0].kind == identToken && tokens[1].is(":") {
+			st.label = tokens[0].text
+			st.col = tokens[0].col
+			a.statements = append(a.statements, st)
+			tokens = tokens[2:]
+			st = &statement{file: name, line: i + 1}
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		st.col = tokens[0].col
+		if tokens[0].kind != identToken {
+			return st.errorf(tokens[0].col, "expected an instruction, found %q", tokens[0].text)
+		}
+		if len(tokens) >= 2 && tokens[1].is("=") {
+			st.constant = tokens[0].text
+			st.operands = [][]token{tokens[2:]}
+			if len(tokens) == 2 {
+				return st.errorf(tokens[1].col, "missing value of %s", st.constant)
+			}
+			a.statements = append(a.statements, st)
+			continue
+		}
+		st.name = strings.ToUpper(tokens[0].text)
+		if st.name == "INCLUDE" {
+			if err := a.include(st, tokens[1:]); err != nil {
+				return err
+			}
+			continue
+		}
+		operands, err := splitOperands(st, tokens[1:])
+		if err != nil {
+			return err
+		}
+		st.operands = operands
+		a.statements = append(a.statements, st)
+	}
+	return nil
+}
+
+// include parses the file named by the operand of an INCLUDE
+func (a *assembler) include(st *statement, operand []token) *Error {
+	if len(operand) != 1 || operand[0].kind != stringToken {
+		return st.errorf(st.col, "INCLUDE expects a file name between quotes")
+	}
+	path := filepath.Join(filepath.Dir(st.file), operand[0].text)
+	if a.including[path] {
+		return st.errorf(operand[0].col, "%s includes itself", path)
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return st.errorf(operand[0].col, "%v", err)
+	}
+	return a.parse(path, src)
+}
+
+// splitOperands splits tokens on the commas
+func splitOperands(st *statement, tokens []token) ([][]token, *Error) {
+	var operands [][]token
+	var operand []token
+	for _, t := range tokens {
+		if t.is(",") {
+			if len(operand) == 0 {
+				return nil, st.errorf(t.col, "missing operand")
+			}
+			operands = append(operands, operand)
+			operand = nil
+			continue
+		}
+		operand = append(operand, t)
+	}
+	if len(operand) == 0 && len(operands) > 0 {
+		return nil, st.errorf(tokens[len(tokens)-1].col, "missing operand")
+	}
+	if len(operand) > 0 {
+		operands = append(operands, operand)
+	}
+	return operands, nil
+}
+
+// layout gives an address to every statement and defines the symbols
+func (a *assembler) layout() *Error {
+	address := origin
+	for _, st := range a.statements {
+		st.address = address
+		switch {
+		case st.label != "":
+			if _, ok := a.symbols[st.label]; ok {
+				return st.errorf(st.col, "%s redefined", st.label)
+			}
+			a.symbols[st.label] = address
+		case st.constant != "":
+			if _, ok := a.symbols[st.constant]; ok {
+				return st.errorf(st.col, "%s redefined", st.constant)
+			}
+			value, err := a.eval(st, st.operands[0])
+			if err != nil {
+				return err
+			}
+			a.symbols[st.constant] = value
+		default:
+			size, err := a.sizeOf(st)
+			if err != nil {
+				return err
+			}
+			st.size = size
+			address += size
+			if address > 0x10000 {
+				return st.errorf(st.col, "program does not fit in memory")
+			}
+		}
+	}
+	return nil
+}
+
+// emit encodes the statements once every symbol is known
+func (a *assembler) emit() ([]byte, *Error) {
+	var rom []byte
+	for _, st := range a.statements {
+		if st.size == 0 {
+			continue
+		}
+		code, err := a.encode(st)
+		if err != nil {
+			return nil, err
+		}
+		rom = append(rom, code...)
+	}
+	return rom, nil
+}
+
+// eval computes an expression made of numbers and symbols added or subtracted
+func (a *assembler) eval(st *statement, expr []token) (int, *Error) {
+	value := 0
+	sign := 1
+	expectTerm := true
+	for _, t := range expr {
+		switch {
+		case expectTerm && (t.is("+") || t.is("-")):
+			if t.is("-") {
+				sign = -sign
+			}
+			continue
+		case expectTerm && t.kind == numberToken:
+			n, err := strconv.ParseInt(t.text, 0, 32)
+			if err != nil {
+				return 0, st.errorf(t.col, "invalid number %s", t.text)
+			}
+			value += sign * int(n)
+		case expectTerm && t.kind == identToken:
+			n, ok := a.symbols[t.text]
+			if !ok {
+				return 0, st.errorf(t.col, "undefined symbol %s", t.text)
+			}
+			value += sign * n
+		case !expectTerm && (t.is("+") || t.is("-")):
+			sign = 1
+			if t.is("-") {
+				sign = -1
+			}
+			expectTerm = true
+			continue
+		default:
+			return 0, st.errorf(t.col, "unexpected %q in expression", t.text)
+		}
+		sign = 1
+		expectTerm = false
+	}
+	if expectTerm {
+		col := st.col
+		if len(expr) > 0 {
+			col = expr[len(expr)-1].col
+		}
+		return 0, st.errorf(col, "missing value")
+	}
+	return value, nil
+}
+
+// value evaluates an operand that must fit in max,
+// negative values down to -(max+1)/2 are stored in two's complement
+func (a *assembler) value(st *statement, operand []token, max int) (uint16, *Error) {
+	value, err := a.eval(st, operand)
+	if err != nil {
+		return 0, err
+	}
+	if value > max || value < -(max+1)/2 {
+		return 0, st.errorf(operand[0].col, "value 0x%X out of range 0x%X", value, max)
+	}
+	return uint16(value & max), nil
+}
@@ -0,0 +1,156 @@
+package asm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AsmTestSuite struct {
+	suite.Suite
+}
+
+func (suite *AsmTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *AsmTestSuite) TestAssemble_Program() {
+	// Adapt
+	src := `
+; draws a box forever
+X = 10
+Y = X + 2
+
+start:  LD V0, X
+        LD V1, Y
+        LD I, box
+loop:   DRW V0, V1, box_end - box
+        JP loop
+
+box:    SPRITE ######## #......#
+        SPRITE ########
+box_end:
+        DB 1, 0x02, -1
+        DW start, 0x1234
+`
+
+	// Act
+	rom, err := Assemble("box.asm", []byte(src))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), []byte{
+		0x60, 0x0A, 0x61, 0x0C, 0xA2, 0x0A, 0xD0, 0x13, 0x12, 0x06,
+		0xFF, 0x81, 0xFF,
+		0x01, 0x02, 0xFF,
+		0x02, 0x00, 0x12, 0x34,
+	}, rom, "Assembled program")
+}
+
+func (suite *AsmTestSuite) TestAssemble_BigSprite() {
+	// Act
+	rom, err := Assemble("big.asm", []byte("SPRITE ########........"+" ........########"))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), []byte{0xFF, 0x00, 0x00, 0xFF}, rom, "Two bytes per row")
+}
+
+func (suite *AsmTestSuite) TestAssemble_Include() {
+	// Adapt
+	dir, _ := ioutil.TempDir("", "asm")
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "lib"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "main.asm"), []byte("CALL clear\nJP 0x202\nINCLUDE \"lib/clear.asm\"\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "lib", "clear.asm"), []byte("clear: CLS\nRET\n"), 0644)
+
+	// Act
+	rom, err := AssembleFile(filepath.Join(dir, "main.asm"))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), []byte{0x22, 0x04, 0x12, 0x02, 0x00, 0xE0, 0x00, 0xEE}, rom, "Included code")
+}
+
+func (suite *AsmTestSuite) TestAssemble_IncludeItself() {
+	// Adapt
+	dir, _ := ioutil.TempDir("", "asm")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "loop.asm")
+	ioutil.WriteFile(path, []byte("CLS\nINCLUDE \"loop.asm\"\n"), 0644)
+
+	// Act
+	_, err := AssembleFile(path)
+
+	// Assert
+	assert.EqualError(suite.T(), err, path+":2:9: "+path+" includes itself", "Include cycle")
+}
+
+func (suite *AsmTestSuite) TestAssemble_Errors() {
+	// Adapt
+	expected := map[string]string{
+		"a: CLS\na: CLS": "test.asm:2:1: a redefined",
+		"CLS\n  JP end":  "test.asm:2:6: undefined symbol end",
+		"X =":            "test.asm:1:3: missing value of X",
+		"LD V0,, V1":     "test.asm:1:7: missing operand",
+		"LD V0, 1 2":     "test.asm:1:10: unexpected \"2\" in expression",
+		"0x200":          "test.asm:1:1: expected an instruction, found \"0x200\"",
+	}
+
+	for src, msg := range expected {
+		// Act
+		_, err := Assemble("test.asm", []byte(src))
+
+		// Assert
+		assert.EqualError(suite.T(), err, msg, src)
+	}
+}
+
+func (suite *AsmTestSuite) TestAssemble_Disassembly() {
+	// Adapt
+	rom, _ := ioutil.ReadFile("../rom/PONG")
+	var src []string
+	for _, in := range disasm.Listing(rom, chip8.QuirksVIP) {
+		src = append(src, in.Mnemonic)
+	}
+
+	// Act
+	assembled, err := Assemble("pong.asm", []byte(strings.Join(src, "\n")))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), rom, assembled, "Same rom as the disassembled one")
+}
+
+func (suite *AsmTestSuite) TestAssemble_Runs() {
+	// Adapt
+	src := "LD V0, 5\nloop: ADD V1, 2\nADD V0, -1\nSE V0, 0\nJP loop\nEXIT\n"
+	rom, _ := Assemble("run.asm", []byte(src))
+	path := filepath.Join(os.TempDir(), "run.ch8")
+	ioutil.WriteFile(path, rom, 0644)
+	defer os.Remove(path)
+	m := chip8.NewMemory(chip8.QuirksSCHIP)
+	m.LoadRom(path)
+
+	// Act
+	var err error
+	for err == nil {
+		err = m.Iterate()
+	}
+
+	// Assert
+	assert.Equal(suite.T(), chip8.ErrHalted, err, "Program exited")
+	assert.Equal(suite.T(), byte(10), m.V[1], "Loop ran five times")
+}
+
+func TestAsmTestSuite(t *testing.T) {
+	suite.Run(t, new(AsmTestSuite))
+}
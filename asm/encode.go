@@ -0,0 +1,307 @@
+package asm
+
+import "strings"
+
+// operandKind is what an operand of an instruction form accepts
+type operandKind int
+
+const (
+	// vx and vy are registers stored in the X and Y nibbles
+	vx operandKind = iota
+	vy
+	// n, nn and nnn are values stored in the lowest nibbles
+	n
+	nn
+	nnn
+	// xn is a value stored in the X nibble
+	xn
+	// the keywords name the other registers
+	kwI
+	kwDT
+	kwST
+	kwK
+	kwF
+	kwHF
+	kwB
+	kwR
+	kwIndirect
+)
+
+// keywords are the operands naming registers other than V0 to VF
+var keywords = map[string]operandKind{
+	"I": kwI, "DT": kwDT, "ST": kwST, "K": kwK, "F": kwF,
+	"HF": kwHF, "B": kwB, "R": kwR, "[I]": kwIndirect,
+}
+
+// form is a list of operands accepted by a mnemonic and its opcode
+type form struct {
+	operands []operandKind
+	opcode   uint16
+}
+
+// forms are the instructions by mnemonic
+var forms = map[string][]form{
+	"CLS":  {{nil, 0x00E0}},
+	"RET":  {{nil, 0x00EE}},
+	"SYS":  {{[]operandKind{nnn}, 0x0000}},
+	"SCD":  {{[]operandKind{n}, 0x00C0}},
+	"SCU":  {{[]operandKind{n}, 0x00D0}},
+	"SCR":  {{nil, 0x00FB}},
+	"SCL":  {{nil, 0x00FC}},
+	"EXIT": {{nil, 0x00FD}},
+	"LOW":  {{nil, 0x00FE}},
+	"HIGH": {{nil, 0x00FF}},
+	"JP":   {{[]operandKind{nnn}, 0x1000}, {[]operandKind{vx, nnn}, 0xB000}},
+	"CALL": {{[]operandKind{nnn}, 0x2000}},
+	"SE":   {{[]operandKind{vx, vy}, 0x5000}, {[]operandKind{vx, nn}, 0x3000}},
+	"SNE":  {{[]operandKind{vx, vy}, 0x9000}, {[]operandKind{vx, nn}, 0x4000}},
+	"SAVE": {{[]operandKind{vx, vy}, 0x5002}},
+	"LOAD": {{[]operandKind{vx, vy}, 0x5003}},
+	"LD": {
+		{[]operandKind{vx, vy}, 0x8000},
+		{[]operandKind{vx, kwDT}, 0xF007},
+		{[]operandKind{vx, kwK}, 0xF00A},
+		{[]operandKind{vx, kwIndirect}, 0xF065},
+		{[]operandKind{vx, kwR}, 0xF085},
+		{[]operandKind{vx, nn}, 0x6000},
+		{[]operandKind{kwI, nnn}, 0xA000},
+		{[]operandKind{kwDT, vx}, 0xF015},
+		{[]operandKind{kwST, vx}, 0xF018},
+		{[]operandKind{kwF, vx}, 0xF029},
+		{[]operandKind{kwHF, vx}, 0xF030},
+		{[]operandKind{kwB, vx}, 0xF033},
+		{[]operandKind{kwIndirect, vx}, 0xF055},
+		{[]operandKind{kwR, vx}, 0xF075},
+	},
+	"ADD": {
+		{[]operandKind{vx, vy}, 0x8004},
+		{[]operandKind{vx, nn}, 0x7000},
+		{[]operandKind{kwI, vx}, 0xF01E},
+	},
+	"OR":    {{[]operandKind{vx, vy}, 0x8001}},
+	"AND":   {{[]operandKind{vx, vy}, 0x8002}},
+	"XOR":   {{[]operandKind{vx, vy}, 0x8003}},
+	"SUB":   {{[]operandKind{vx, vy}, 0x8005}},
+	"SHR":   {{[]operandKind{vx, vy}, 0x8006}, {[]operandKind{vx}, 0x8006}},
+	"SUBN":  {{[]operandKind{vx, vy}, 0x8007}},
+	"SHL":   {{[]operandKind{vx, vy}, 0x800E}, {[]operandKind{vx}, 0x800E}},
+	"RND":   {{[]operandKind{vx, nn}, 0xC000}},
+	"DRW":   {{[]operandKind{vx, vy, n}, 0xD000}},
+	"SKP":   {{[]operandKind{vx}, 0xE09E}},
+	"SKNP":  {{[]operandKind{vx}, 0xE0A1}},
+	"PLANE": {{[]operandKind{xn}, 0xF001}},
+	"AUDIO": {{nil, 0xF002}},
+	"PITCH": {{[]operandKind{vx}, 0xF03A}},
+}
+
+// register returns the number of the V register named by an operand
+func register(operand []token) (uint16, bool) {
+	if len(operand) != 1 || operand[0].kind != identToken || len(operand[0].text) != 2 {
+		return 0, false
+	}
+	text := strings.ToUpper(operand[0].text)
+	if text[0] != 'V' {
+		return 0, false
+	}
+	i := strings.IndexByte("0123456789ABCDEF", text[1])
+	return uint16(i), i >= 0
+}
+
+// keyword returns the register other than V0 to VF named by an operand
+func keyword(operand []token) (operandKind, bool) {
+	var text string
+	for _, t := range operand {
+		text += strings.ToUpper(t.text)
+	}
+	kind, ok := keywords[text]
+	return kind, ok
+}
+
+// matches tells if the operands fit the form
+func (f form) matches(operands [][]token) bool {
+	if len(operands) != len(f.operands) {
+		return false
+	}
+	for i, kind := range f.operands {
+		_, isRegister := register(operands[i])
+		k, isKeyword := keyword(operands[i])
+		switch kind {
+		case vx, vy:
+			if !isRegister {
+				return false
+			}
+		case n, nn, nnn, xn:
+			if isRegister || isKeyword {
+				return false
+			}
+		default:
+			if !isKeyword || k != kind {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isLongLoad tells if the statement is the four bytes XO-CHIP LD I,
+// chosen with the LONG keyword or by an address over 0xFFF
+func (a *assembler) isLongLoad(st *statement) bool {
+	if st.name != "LD" || len(st.operands) != 2 {
+		return false
+	}
+	if k, ok := keyword(st.operands[0]); !ok || k != kwI {
+		return false
+	}
+	operand := st.operands[1]
+	if operand[0].kind == identToken && strings.ToUpper(operand[0].text) == "LONG" {
+		return true
+	}
+	if _, ok := register(operand); ok {
+		return false
+	}
+	value, err := a.eval(st, operand)
+	return err == nil && value > 0xFFF
+}
+
+// sizeOf returns the number of bytes of an instruction or a data directive
+func (a *assembler) sizeOf(st *statement) (int, *Error) {
+	switch st.name {
+	case "DB":
+		return len(st.operands), nil
+	case "DW":
+		return 2 * len(st.operands), nil
+	case "SPRITE":
+		data, err := sprite(st)
+		return len(data), err
+	}
+	if _, ok := forms[st.name]; !ok {
+		return 0, st.errorf(st.col, "unknown instruction %s", st.name)
+	}
+	if a.isLongLoad(st) {
+		return 4, nil
+	}
+	return 2, nil
+}
+
+// encode returns the bytes of an instruction or a data directive
+func (a *assembler) encode(st *statement) ([]byte, *Error) {
+	switch st.name {
+	case "DB":
+		return a.data(st, 1)
+	case "DW":
+		return a.data(st, 2)
+	case "SPRITE":
+		return sprite(st)
+	}
+	if st.size == 4 {
+		operand := st.operands[1]
+		if strings.ToUpper(operand[0].text) == "LONG" {
+			operand = operand[1:]
+		}
+		address, err := a.value(st, operand, 0xFFFF)
+		if err != nil {
+			return nil, err
+		}
+		return []byte{0xF0, 0x00, byte(address >> 8), byte(address)}, nil
+	}
+	for _, f := range forms[st.name] {
+		if f.matches(st.operands) {
+			opcode, err := a.encodeForm(st, f)
+			if err != nil {
+				return nil, err
+			}
+			return []byte{byte(opcode >> 8), byte(opcode)}, nil
+		}
+	}
+	return nil, st.errorf(st.col, "invalid operands for %s", st.name)
+}
+
+// encodeForm returns the opcode of an instruction matching the form
+func (a *assembler) encodeForm(st *statement, f form) (uint16, *Error) {
+	opcode := f.opcode
+	for i, kind := range f.operands {
+		operand := st.operands[i]
+		r, _ := register(operand)
+		switch kind {
+		case vx:
+			opcode |= r << 8
+		case vy:
+			opcode |= r << 4
+		case n, xn, nn, nnn:
+			max := map[operandKind]int{n: 0xF, xn: 0xF, nn: 0xFF, nnn: 0xFFF}[kind]
+			value, err := a.value(st, operand, max)
+			if err != nil {
+				return 0, err
+			}
+			if kind == xn {
+				value <<= 8
+			}
+			opcode |= value
+		}
+	}
+	if f.opcode == 0x8006 || f.opcode == 0x800E {
+		if len(f.operands) == 1 {
+			opcode |= opcode >> 4 & 0xF0
+		}
+	}
+	if f.opcode == 0xB000 {
+		// BXNN jumps to XNN + VX, the register must be V0 or the high nibble of the address
+		r, _ := register(st.operands[0])
+		address, _ := a.value(st, st.operands[1], 0xFFF)
+		opcode = 0xB000 | address
+		if r != 0 && address>>8 != r {
+			return 0, st.errorf(st.operands[0][0].col, "JP V%X needs an address in 0x%X00-0x%XFF", r, r, r)
+		}
+	}
+	return opcode, nil
+}
+
+// data returns the values of a DB or DW directive
+func (a *assembler) data(st *statement, size int) ([]byte, *Error) {
+	var data []byte
+	for _, operand := range st.operands {
+		if size == 1 {
+			value, err := a.value(st, operand, 0xFF)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, byte(value))
+			continue
+		}
+		value, err := a.value(st, operand, 0xFFFF)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(value>>8), byte(value))
+	}
+	return data, nil
+}
+
+// sprite returns the bytes of a SPRITE directive,
+// each row is drawn with # for a lit pixel and . for a dark one,
+// 8 pixels wide or 16 pixels wide for the SUPER-CHIP big sprites
+func sprite(st *statement) ([]byte, *Error) {
+	var data []byte
+	for _, operand := range st.operands {
+		for _, row := range operand {
+			if row.kind != bitmapToken || len(row.text) != 8 && len(row.text) != 16 {
+				return nil, st.errorf(row.col, "sprite rows are 8 or 16 pixels of # and .")
+			}
+			var bits uint16
+			for _, c := range row.text {
+				bits <<= 1
+				if c == '#' {
+					bits |= 1
+				}
+			}
+			if len(row.text) == 16 {
+				data = append(data, byte(bits>>8))
+			}
+			data = append(data, byte(bits))
+		}
+	}
+	if len(data) == 0 {
+		return nil, st.errorf(st.col, "empty sprite")
+	}
+	return data, nil
+}
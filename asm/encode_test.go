@@ -0,0 +1,85 @@
+package asm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncodeTestSuite struct {
+	suite.Suite
+}
+
+// assembleLine assembles a single instruction
+func assembleLine(line string) ([]byte, error) {
+	return Assemble("test.asm", []byte(line))
+}
+
+func (suite *EncodeTestSuite) TestEncode() {
+	// Adapt
+	expected := map[string]uint16{
+		"CLS": 0x00E0, "RET": 0x00EE, "SYS 0x123": 0x0123,
+		"SCD 4": 0x00C4, "SCU 4": 0x00D4, "SCR": 0x00FB, "SCL": 0x00FC,
+		"EXIT": 0x00FD, "LOW": 0x00FE, "HIGH": 0x00FF,
+		"JP 0x234": 0x1234, "JP V0, 0x123": 0xB123, "JP V1, 0x123": 0xB123, "CALL 0x345": 0x2345,
+		"SE VA, 0x42": 0x3A42, "SNE VB, 0x42": 0x4B42, "SE V1, V2": 0x5120, "SNE V1, V2": 0x9120,
+		"SAVE V1, V2": 0x5122, "LOAD V1, V2": 0x5123,
+		"LD V3, 0x42": 0x6342, "ADD V3, 0x42": 0x7342, "ADD V3, -1": 0x73FF,
+		"LD V1, V2": 0x8120, "OR V1, V2": 0x8121, "AND V1, V2": 0x8122, "XOR V1, V2": 0x8123,
+		"ADD V1, V2": 0x8124, "SUB V1, V2": 0x8125, "SHR V1, V2": 0x8126, "SUBN V1, V2": 0x8127,
+		"SHL V1, V2": 0x812E, "SHR V4": 0x8446, "SHL V4": 0x844E,
+		"LD I, 0x123": 0xA123, "RND V1, 0x0F": 0xC10F, "DRW V0, V1, 5": 0xD015,
+		"SKP V1": 0xE19E, "SKNP V1": 0xE1A1,
+		"LD V1, DT": 0xF107, "LD V1, K": 0xF10A, "LD DT, V1": 0xF115, "LD ST, V1": 0xF118,
+		"ADD I, V1": 0xF11E, "LD F, V1": 0xF129, "LD HF, V1": 0xF130, "LD B, V1": 0xF133,
+		"LD [I], V1": 0xF155, "LD V1, [I]": 0xF165, "LD R, V1": 0xF175, "LD V1, R": 0xF185,
+		"PLANE 2": 0xF201, "AUDIO": 0xF002, "PITCH V1": 0xF13A,
+		"ld v1, [i]": 0xF165,
+	}
+
+	for line, opcode := range expected {
+		// Act
+		rom, err := assembleLine(line)
+
+		// Assert
+		assert.Nil(suite.T(), err, line)
+		assert.Equal(suite.T(), []byte{byte(opcode >> 8), byte(opcode)}, rom, line)
+	}
+}
+
+func (suite *EncodeTestSuite) TestEncode_LongLoad() {
+	// Act
+	literal, err := assembleLine("LD I, 0x1234")
+	keyword, err2 := assembleLine("LD I, LONG 0x200")
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Nil(suite.T(), err2, "No error")
+	assert.Equal(suite.T(), []byte{0xF0, 0x00, 0x12, 0x34}, literal, "Over 0xFFF")
+	assert.Equal(suite.T(), []byte{0xF0, 0x00, 0x02, 0x00}, keyword, "LONG keyword")
+}
+
+func (suite *EncodeTestSuite) TestEncode_Errors() {
+	// Adapt
+	expected := map[string]string{
+		"FOO V1":        "test.asm:1:1: unknown instruction FOO",
+		"LD V1":         "test.asm:1:1: invalid operands for LD",
+		"LD V1, 0x100":  "test.asm:1:8: value 0x100 out of range 0xFF",
+		"DRW V0, V1, X": "test.asm:1:13: undefined symbol X",
+		"JP V2, 0x123":  "test.asm:1:4: JP V2 needs an address in 0x200-0x2FF",
+		"SPRITE ###":    "test.asm:1:8: sprite rows are 8 or 16 pixels of # and .",
+	}
+
+	for line, msg := range expected {
+		// Act
+		_, err := assembleLine(line)
+
+		// Assert
+		assert.EqualError(suite.T(), err, msg, line)
+	}
+}
+
+func TestEncodeTestSuite(t *testing.T) {
+	suite.Run(t, new(EncodeTestSuite))
+}
@@ -0,0 +1,80 @@
+package asm
+
+import "strings"
+
+// tokenKind is the kind of a token of a source line
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	numberToken
+	stringToken
+	bitmapToken
+	punctToken
+)
+
+// token is a word of a source line, col is its 1-based column
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+// is tells if the token is the given punctuation
+func (t token) is(punct string) bool {
+	return t.kind == punctToken && t.text == punct
+}
+
+// lex splits a source line in tokens, comments start with a semicolon
+func lex(line string) ([]token, *Error) {
+	var tokens []token
+	for i := 0; i < len(line); {
+		c := line[i]
+		start := i
+		switch {
+		case c == ';':
+			return tokens, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case isLetter(c):
+			for i < len(line) && (isLetter(line[i]) || isDigit(line[i])) {
+				i++
+			}
+			tokens = append(tokens, token{identToken, line[start:i], start + 1})
+		case isDigit(c):
+			for i < len(line) && (isLetter(line[i]) || isDigit(line[i])) {
+				i++
+			}
+			tokens = append(tokens, token{numberToken, line[start:i], start + 1})
+		case c == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end < 0 {
+				return nil, &Error{Column: start + 1, Msg: "unterminated string"}
+			}
+			i += end + 2
+			tokens = append(tokens, token{stringToken, line[start+1 : i-1], start + 1})
+		case c == '#' || c == '.':
+			for i < len(line) && (line[i] == '#' || line[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{bitmapToken, line[start:i], start + 1})
+		case strings.IndexByte(",:=[]+-", c) >= 0:
+			i++
+			tokens = append(tokens, token{punctToken, line[start:i], start + 1})
+		default:
+			return nil, &Error{Column: start + 1, Msg: "unexpected character " + strings.TrimSpace(string(c))}
+		}
+	}
+	return tokens, nil
+}
+
+// isLetter tells if c can start an identifier
+func isLetter(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// isDigit tells if c is a decimal digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
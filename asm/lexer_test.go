@@ -0,0 +1,51 @@
+package asm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LexerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *LexerTestSuite) TestLex() {
+	// Act
+	tokens, err := lex("loop: LD [I], V3 ; comment")
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), []token{
+		{identToken, "loop", 1}, {punctToken, ":", 5}, {identToken, "LD", 7},
+		{punctToken, "[", 10}, {identToken, "I", 11}, {punctToken, "]", 12},
+		{punctToken, ",", 13}, {identToken, "V3", 15},
+	}, tokens, "Tokens and their columns")
+}
+
+func (suite *LexerTestSuite) TestLex_NumbersStringsBitmaps() {
+	// Act
+	tokens, err := lex(`0x1F 42 "file.asm" ##..##..`)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), []token{
+		{numberToken, "0x1F", 1}, {numberToken, "42", 6},
+		{stringToken, "file.asm", 9}, {bitmapToken, "##..##..", 20},
+	}, tokens, "Tokens and their columns")
+}
+
+func (suite *LexerTestSuite) TestLex_Errors() {
+	// Act
+	_, unterminated := lex(`INCLUDE "file`)
+	_, unexpected := lex("LD V0, @")
+
+	// Assert
+	assert.Equal(suite.T(), 9, unterminated.Column, "Column of the string")
+	assert.Equal(suite.T(), 8, unexpected.Column, "Column of the character")
+}
+
+func TestLexerTestSuite(t *testing.T) {
+	suite.Run(t, new(LexerTestSuite))
+}
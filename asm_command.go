@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/asm"
+)
+
+// asmCommand assembles a source file into a .ch8 rom
+func asmCommand(args []string) error {
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	output := flags.String("o", "", "rom to write, the source file with the .ch8 extension if not set")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: program asm [options] filepath")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	source := flags.Arg(0)
+	rom, err := asm.AssembleFile(source)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(source, filepath.Ext(source)) + ".ch8"
+	}
+	return ioutil.WriteFile(*output, rom, 0644)
+}
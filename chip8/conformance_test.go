@@ -0,0 +1,162 @@
+package chip8_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/headless"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// conformanceDir holds the conformance roms, see report.asm for their results.
+// They are written for this emulator and only add to the community suite of
+// suite_test.go.
+const conformanceDir = "../test/conformance"
+
+// conformanceFrames is how long a conformance rom runs, far more than needed
+const conformanceFrames = 120
+
+// checkName matches the calls reporting a check, the comment names the check
+var checkName = regexp.MustCompile(`(?i)^\s*CALL\s+(expect|check|report)\s*;\s*(.+?)\s*$`)
+
+type ConformanceTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConformanceTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// checkNames returns the names of the checks of a rom, in the order of their marks
+func checkNames(rom string) []string {
+	src, err := ioutil.ReadFile(filepath.Join(conformanceDir, rom))
+	if err != nil {
+		panic(err)
+	}
+	var names []string
+	for _, line := range strings.Split(string(src), "\n") {
+		if match := checkName.FindStringSubmatch(line); match != nil {
+			names = append(names, match[2])
+		}
+	}
+	return names
+}
+
+// runConformance assembles a rom after the constant definitions of prelude,
+// then runs it with the quirks and the key script
+func runConformance(rom, prelude string, q chip8.Quirks, script headless.Script) (*chip8.Memory, error) {
+	src := prelude + fmt.Sprintf("INCLUDE %q\n", rom)
+	code, err := asm.Assemble(filepath.Join(conformanceDir, "main.asm"), []byte(src))
+	if err != nil {
+		return nil, err
+	}
+	m := chip8.NewSeededMemory(q, 1)
+	copy(m.Memory[0x200:], code)
+	return m, headless.Run(m, headless.Options{Frames: conformanceFrames, Script: script})
+}
+
+// mark returns what is drawn in the cell of the check i:
+// "passed" for a block, "failed" for a cross, "not run" when empty
+func mark(m *chip8.Memory, i int) string {
+	x, y := i%10*6, i/10*6
+	lit := 0
+	for dx := 0; dx < 4; dx++ {
+		for dy := 0; dy < 4; dy++ {
+			if m.Screen[x+dx][y+dy] {
+				lit++
+			}
+		}
+	}
+	switch lit {
+	case 16:
+		return "passed"
+	case 8:
+		return "failed"
+	case 0:
+		return "not run"
+	}
+	return "garbled"
+}
+
+// assertChecks reports every check of a rom as a subtest
+func (suite *ConformanceTestSuite) assertChecks(rom, prelude string, q chip8.Quirks, script headless.Script) {
+	names := checkNames(rom)
+	if !assert.True(suite.T(), len(names) > 0 && len(names) <= 50, "1 to 50 checks in %s", rom) {
+		return
+	}
+
+	// Act
+	m, err := runConformance(rom, prelude, q, script)
+
+	// Assert
+	if !assert.Nil(suite.T(), err, "Run of %s", rom) && m == nil {
+		return
+	}
+	for i, name := range names {
+		i := i
+		suite.Run(name, func() {
+			assert.Equal(suite.T(), "passed", mark(m, i))
+		})
+	}
+}
+
+func (suite *ConformanceTestSuite) TestOpcode() {
+	suite.assertChecks("opcode.asm", "", chip8.QuirksVIP, nil)
+}
+
+func (suite *ConformanceTestSuite) TestFlags() {
+	suite.assertChecks("flags.asm", "", chip8.QuirksVIP, nil)
+}
+
+func (suite *ConformanceTestSuite) TestKeypad() {
+	// Adapt
+	script := headless.Script{
+		{Frame: 10, Key: 5, Pressed: true},
+		{Frame: 15, Key: 5},
+		{Frame: 30, Key: 7, Pressed: true},
+	}
+
+	suite.assertChecks("keypad.asm", "", chip8.QuirksVIP, script)
+}
+
+// quirksExpected is the prelude of quirks.asm for each preset, written from
+// the documented behavior of the platforms and not from the presets, so a
+// wrong preset fails the checks
+var quirksExpected = map[string]string{
+	// the VIP clears VF in the logic opcodes, shifts VY, moves I past
+	// the registers, jumps to NNN + V0 and clips the sprites
+	"vip": "VF_RESET = 1\nSHIFT_VY = 1\nLOAD_STORE = 1\nJUMP_VX = 0\nCLIP = 1\n",
+	// the CHIP-48 shifts VX in place, moves I by X only and jumps to XNN + VX
+	"chip48": "VF_RESET = 0\nSHIFT_VY = 0\nLOAD_STORE = 2\nJUMP_VX = 1\nCLIP = 1\n",
+	// the SUPER-CHIP 1.1 is the CHIP-48 leaving I unchanged
+	"schip": "VF_RESET = 0\nSHIFT_VY = 0\nLOAD_STORE = 0\nJUMP_VX = 1\nCLIP = 1\n",
+	// Octo runs the XO-CHIP like the VIP but keeps VF and wraps the sprites
+	"xochip": "VF_RESET = 0\nSHIFT_VY = 1\nLOAD_STORE = 1\nJUMP_VX = 0\nCLIP = 0\n",
+}
+
+func (suite *ConformanceTestSuite) TestQuirks() {
+	for _, name := range chip8.QuirksNames() {
+		q, _ := chip8.QuirksByName(name)
+		suite.Run(name, func() {
+			// Adapt
+			prelude, ok := quirksExpected[name]
+			if !assert.True(suite.T(), ok, "Expected behavior of %s", name) {
+				return
+			}
+
+			suite.assertChecks("quirks.asm", prelude, q, nil)
+		})
+	}
+}
+
+func TestConformanceTestSuite(t *testing.T) {
+	suite.Run(t, new(ConformanceTestSuite))
+}
@@ -0,0 +1,303 @@
+package chip8_test
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/chip8/internal/reference"
+	"github.com/Oicho/GO-Chip8/disasm"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	diffCases = flag.Int("diff.cases", 300, "number of random instruction streams of the differential test")
+	diffSeed  = flag.Int64("diff.seed", 1, "seed of the first random instruction stream of the differential test")
+)
+
+// diffSteps is the length of a random instruction stream
+const diffSteps = 200
+
+// diffProfiles are the quirks known by the reference interpreter
+var diffProfiles = []chip8.Quirks{chip8.QuirksVIP, chip8.QuirksCHIP48, {}}
+
+// diffStep is an opcode executed while the keys are held
+type diffStep struct {
+	Opcode uint16
+	Keys   uint16
+}
+
+// opcodeTemplates are the instructions of the random streams,
+// the bits of the mask are random
+var opcodeTemplates = []struct{ base, mask uint16 }{
+	{0x00E0, 0}, {0x00EE, 0}, {0x0000, 0x0FFF}, {0x1000, 0xFFF}, {0x2000, 0xFFF},
+	{0x3000, 0xFFF}, {0x4000, 0xFFF}, {0x5000, 0xFF0}, {0x5000, 0xFFF}, {0x6000, 0xFFF},
+	{0x7000, 0xFFF}, {0x8000, 0xFF7}, {0x8000, 0xFFF}, {0x800E, 0xFF0}, {0x9000, 0xFF0},
+	{0xA000, 0xFFF}, {0xA200, 0x1FF}, {0xB000, 0xFFF}, {0xC000, 0xFFF}, {0xD000, 0xFFF},
+	{0xE09E, 0xF00}, {0xE0A1, 0xF00}, {0xF007, 0xF00}, {0xF00A, 0xF00}, {0xF015, 0xF00},
+	{0xF018, 0xF00}, {0xF01E, 0xF00}, {0xF029, 0xF00}, {0xF033, 0xF00}, {0xF055, 0xF00},
+	{0xF065, 0xF00}, {0x0000, 0xFFFF},
+}
+
+// randomSteps returns a random instruction stream, the keys seldom change
+func randomSteps(r *rand.Rand, n int) []diffStep {
+	steps := make([]diffStep, n)
+	keys := uint16(0)
+	for i := range steps {
+		switch r.Intn(20) {
+		case 0:
+			keys = 0
+		case 1:
+			keys = 1 << uint(r.Intn(16))
+		}
+		t := opcodeTemplates[r.Intn(len(opcodeTemplates))]
+		steps[i] = diffStep{t.base | uint16(r.Intn(0x10000))&t.mask, keys}
+	}
+	return steps
+}
+
+// newDiffPair creates a chip8 and a reference machine in the same random state
+func newDiffPair(seed int64) (*chip8.Memory, *reference.Machine) {
+	r := rand.New(rand.NewSource(seed))
+	q := diffProfiles[r.Intn(len(diffProfiles))]
+	m := chip8.NewSeededMemory(q, seed)
+	for i := range m.V {
+		m.V[i] = byte(r.Intn(0x100))
+	}
+	m.I = uint16(r.Intn(0x1000))
+	if r.Intn(4) == 0 {
+		// near the end of the memory to reach the faults
+		m.I = uint16(0x1000 - r.Intn(0x20))
+	}
+	m.PC = 0x200 + uint16(r.Intn(0x700))*2
+	m.SP = uint16(r.Intn(m.StackDepth() + 1))
+	for i := uint16(0); i < m.SP; i++ {
+		m.CallStack[i] = 0x200 + uint16(r.Intn(0xE00))
+	}
+	m.DelayTimer = byte(r.Intn(0x100))
+	m.SoundTimer = byte(r.Intn(0x100))
+	r.Read(m.Memory[0x200:0x1000])
+	for x := range m.Screen {
+		for y := range m.Screen[x] {
+			m.Screen[x][y] = r.Intn(8) == 0
+		}
+	}
+	m.Input = &chip8.Keypad{}
+
+	ref := &reference.Machine{
+		V:      m.V,
+		I:      m.I,
+		PC:     m.PC,
+		Stack:  append([]uint16{}, m.CallStack[:m.SP]...),
+		DT:     m.DelayTimer,
+		ST:     m.SoundTimer,
+		Quirks: q,
+	}
+	copy(ref.Memory[:], m.Memory[:reference.MemorySize])
+	for x := range m.Screen {
+		for y := range m.Screen[x] {
+			ref.Screen[y][x] = m.Screen[x][y]
+		}
+	}
+	rng := m.Rand
+	ref.Random = rng.Byte
+	return m, ref
+}
+
+// setKeys holds the keys of the bit set on both machines
+func setKeys(m *chip8.Memory, ref *reference.Machine, keys uint16) {
+	keypad := m.Input.(*chip8.Keypad)
+	keypad.ReleaseAll()
+	for k := byte(0); k < 0x10; k++ {
+		if keys&(1<<k) != 0 {
+			keypad.Press(k)
+		}
+	}
+	ref.Keys = keys
+}
+
+// stateDiff returns the first difference between the machines, "" if there is none
+func stateDiff(m *chip8.Memory, ref *reference.Machine) string {
+	for i := range m.V {
+		if m.V[i] != ref.V[i] {
+			return fmt.Sprintf("V%X=0x%02X, reference 0x%02X", i, m.V[i], ref.V[i])
+		}
+	}
+	switch {
+	case m.I != ref.I:
+		return fmt.Sprintf("I=0x%04X, reference 0x%04X", m.I, ref.I)
+	case m.PC != ref.PC:
+		return fmt.Sprintf("PC=0x%04X, reference 0x%04X", m.PC, ref.PC)
+	case int(m.SP) != len(ref.Stack):
+		return fmt.Sprintf("SP=%d, reference %d", m.SP, len(ref.Stack))
+	case m.DelayTimer != ref.DT:
+		return fmt.Sprintf("DT=0x%02X, reference 0x%02X", m.DelayTimer, ref.DT)
+	case m.SoundTimer != ref.ST:
+		return fmt.Sprintf("ST=0x%02X, reference 0x%02X", m.SoundTimer, ref.ST)
+	}
+	for i, address := range ref.Stack {
+		if m.CallStack[i] != address {
+			return fmt.Sprintf("stack[%d]=0x%04X, reference 0x%04X", i, m.CallStack[i], address)
+		}
+	}
+	for address := range ref.Memory {
+		if m.Memory[address] != ref.Memory[address] {
+			return fmt.Sprintf("[0x%04X]=0x%02X, reference 0x%02X", address, m.Memory[address], ref.Memory[address])
+		}
+	}
+	for x := range m.Screen {
+		for y := range m.Screen[x] {
+			if m.Screen[x][y] != ref.Screen[y][x] {
+				return fmt.Sprintf("pixel %d,%d is %t, reference %t", x, y, m.Screen[x][y], ref.Screen[y][x])
+			}
+		}
+	}
+	return ""
+}
+
+// runDiff runs the steps on both machines and returns the index
+// of the first step after which they differ, or -1 and ""
+func runDiff(seed int64, steps []diffStep) (int, string) {
+	m, ref := newDiffPair(seed)
+	for i, step := range steps {
+		setKeys(m, ref, step.Keys)
+		err := m.Decode(step.Opcode)
+		refErr := ref.Step(step.Opcode)
+		if (err != nil) != (refErr != nil) {
+			return i, fmt.Sprintf("error %v, reference %v", err, refErr)
+		}
+		if diff := stateDiff(m, ref); diff != "" {
+			return i, diff
+		}
+	}
+	return -1, ""
+}
+
+// stepSimplifications make a step simpler: no key held or a cleared operand nibble
+var stepSimplifications = []func(diffStep) diffStep{
+	func(s diffStep) diffStep { return diffStep{s.Opcode, 0} },
+	func(s diffStep) diffStep { return diffStep{s.Opcode &^ 0x00F, s.Keys} },
+	func(s diffStep) diffStep { return diffStep{s.Opcode &^ 0x0F0, s.Keys} },
+	func(s diffStep) diffStep { return diffStep{s.Opcode &^ 0xF00, s.Keys} },
+}
+
+// shrink returns a shorter and simpler stream that still fails,
+// it removes chunks of steps then simplifies each step until nothing changes
+func shrink(steps []diffStep, fails func([]diffStep) bool) []diffStep {
+	steps = append([]diffStep{}, steps...)
+	for changed := true; changed; {
+		changed = false
+		for size := len(steps) / 2; size > 0; size /= 2 {
+			for i := 0; i+size <= len(steps); {
+				candidate := append(append([]diffStep{}, steps[:i]...), steps[i+size:]...)
+				if fails(candidate) {
+					steps, changed = candidate, true
+				} else {
+					i += size
+				}
+			}
+		}
+		for i := range steps {
+			for _, simplify := range stepSimplifications {
+				step := simplify(steps[i])
+				if step == steps[i] {
+					continue
+				}
+				candidate := append([]diffStep{}, steps...)
+				candidate[i] = step
+				if fails(candidate) {
+					steps[i], changed = step, true
+				}
+			}
+		}
+	}
+	return steps
+}
+
+// describe lists the steps with their mnemonics
+func describe(steps []diffStep, q chip8.Quirks) string {
+	var lines []string
+	for _, step := range steps {
+		in := disasm.Decode([]byte{byte(step.Opcode >> 8), byte(step.Opcode)}, 0, q)
+		lines = append(lines, fmt.Sprintf("  %04X keys %04X  %s", step.Opcode, step.Keys, in.Mnemonic))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type DifferentialTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DifferentialTestSuite) SetupTest() {
+	myLogger.Discard()
+}
+
+func (suite *DifferentialTestSuite) TestRandomStreams() {
+	for seed := *diffSeed; seed < *diffSeed+int64(*diffCases); seed++ {
+		// Adapt
+		r := rand.New(rand.NewSource(seed))
+		steps := randomSteps(r, diffSteps)
+
+		// Act
+		failed, diff := runDiff(seed, steps)
+
+		// Assert
+		if failed < 0 {
+			continue
+		}
+		seed := seed
+		minimal := shrink(steps[:failed+1], func(steps []diffStep) bool {
+			i, _ := runDiff(seed, steps)
+			return i >= 0
+		})
+		_, diff = runDiff(seed, minimal)
+		m, _ := newDiffPair(seed)
+		suite.Fail("Differs from the reference",
+			"seed %d (-diff.seed %d -diff.cases 1): %s after\n%s", seed, seed, diff, describe(minimal, m.Quirks))
+		return
+	}
+}
+
+func (suite *DifferentialTestSuite) TestSameState() {
+	// Adapt
+	m, ref := newDiffPair(42)
+
+	// Act
+	diff := stateDiff(m, ref)
+
+	// Assert
+	assert.Equal(suite.T(), "", diff, "Same initial state")
+}
+
+func (suite *DifferentialTestSuite) TestShrink() {
+	// Adapt
+	r := rand.New(rand.NewSource(3))
+	steps := randomSteps(r, 50)
+	steps[10] = diffStep{0x6A17, 0x0100}
+	steps[30] = diffStep{0x8AB4, 0}
+	fails := func(steps []diffStep) bool {
+		set := false
+		for _, step := range steps {
+			set = set || step.Opcode&0xFF00 == 0x6A00
+			if set && step.Opcode&0xF00F == 0x8004 {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Act
+	minimal := shrink(steps, fails)
+
+	// Assert
+	assert.Equal(suite.T(), []diffStep{{0x6A00, 0}, {0x8004, 0}}, minimal, "Minimal stream")
+}
+
+func TestDifferentialTestSuite(t *testing.T) {
+	suite.Run(t, new(DifferentialTestSuite))
+}
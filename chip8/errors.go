@@ -0,0 +1,96 @@
+package chip8
+
+import (
+	"strconv"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+)
+
+// InvalidOpcodeError is returned when the chip8 meets an opcode
+// it does not know, PC is left on the faulty instruction
+type InvalidOpcodeError struct {
+	PC     uint16
+	Opcode uint16
+}
+
+func (e *InvalidOpcodeError) Error() string {
+	return "invalid opcode 0x" + myLogger.Uint16ToString(e.Opcode) +
+		" at 0x" + myLogger.Uint16ToString(e.PC)
+}
+
+// MemoryFaultError is returned when an instruction accesses memory
+// outside of the address space, PC is left on the faulty instruction
+type MemoryFaultError struct {
+	PC     uint16
+	Opcode uint16
+	// Address is the first address of the access
+	Address uint16
+	// Size is the number of bytes accessed from Address
+	Size uint16
+}
+
+func (e *MemoryFaultError) Error() string {
+	return "memory fault at 0x" + myLogger.Uint16ToString(e.PC) +
+		": opcode 0x" + myLogger.Uint16ToString(e.Opcode) +
+		" accesses " + strconv.Itoa(int(e.Size)) +
+		" bytes from 0x" + myLogger.Uint16ToString(e.Address)
+}
+
+// StackOverflowError is returned when 2NNN is called with a full call stack,
+// PC is left on the call
+type StackOverflowError struct {
+	PC uint16
+	// Stack holds the return addresses on the stack, the last one on top
+	Stack []uint16
+}
+
+func (e *StackOverflowError) Error() string {
+	return "stack overflow at 0x" + myLogger.Uint16ToString(e.PC) +
+		": stack " + formatStack(e.Stack)
+}
+
+// StackUnderflowError is returned when 00EE is called with an empty call stack,
+// PC is left on the return
+type StackUnderflowError struct {
+	PC uint16
+	// Stack holds the return addresses on the stack, it is always empty
+	Stack []uint16
+}
+
+func (e *StackUnderflowError) Error() string {
+	return "stack underflow at 0x" + myLogger.Uint16ToString(e.PC) +
+		": stack " + formatStack(e.Stack)
+}
+
+// formatStack returns the stack addresses in hexadecimal, bottom first
+func formatStack(stack []uint16) string {
+	s := "["
+	for i, address := range stack {
+		if i > 0 {
+			s += " "
+		}
+		s += "0x" + myLogger.Uint16ToString(address)
+	}
+	return s + "]"
+}
+
+// stackContent returns a copy of the return addresses on the call stack
+func (m *Memory) stackContent() []uint16 {
+	stack := make([]uint16, m.SP)
+	copy(stack, m.CallStack[:m.SP])
+	return stack
+}
+
+// invalidOpcode creates the error for an unknown opcode at PC
+func (m *Memory) invalidOpcode(opcode uint16) error {
+	return &InvalidOpcodeError{PC: m.PC, Opcode: opcode}
+}
+
+// checkAddress returns a MemoryFaultError if the size bytes
+// starting at address are not all inside the address space
+func (m *Memory) checkAddress(opcode uint16, address uint16, size uint16) error {
+	if int(address)+int(size) > m.MemorySize() {
+		return &MemoryFaultError{PC: m.PC, Opcode: opcode, Address: address, Size: size}
+	}
+	return nil
+}
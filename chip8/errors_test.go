@@ -0,0 +1,105 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ErrorsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ErrorsTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *ErrorsTestSuite) TestInvalidOpcodes() {
+	for _, opcode := range []uint16{0x8128, 0x812D, 0x812F, 0xE1FF, 0xF1FF, 0xF100} {
+		// Adapt
+		m := createBasicMem()
+
+		// Act
+		err := m.Decode(opcode)
+
+		// Assert
+		assert.Equal(suite.T(), &InvalidOpcodeError{PC: 0x200, Opcode: opcode}, err, "Invalid opcode "+myLogger.Uint16ToString(opcode))
+		assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the faulty instruction")
+	}
+}
+
+func (suite *ErrorsTestSuite) TestIterate_InvalidOpcode() {
+	// Adapt
+	m := createBasicMem()
+	m.Memory[0x200] = 0xF1
+	m.Memory[0x201] = 0xFF
+
+	// Act
+	err := m.Iterate()
+
+	// Assert
+	assert.EqualError(suite.T(), err, "invalid opcode 0xf1ff at 0x0200", "Error message")
+}
+
+func (suite *ErrorsTestSuite) TestIterate_PCOutOfMemory() {
+	// Adapt
+	m := createBasicMem()
+	m.PC = 0xFFF
+
+	// Act
+	err := m.Iterate()
+
+	// Assert
+	assert.IsType(suite.T(), &MemoryFaultError{}, err, "Fetch out of memory")
+}
+
+func (suite *ErrorsTestSuite) TestMemoryFaults() {
+	for _, opcode := range []uint16{0xFF55, 0xFF65, 0xF033, 0xD01F} {
+		// Adapt
+		m := createBasicMem()
+		m.I = 0xFFE
+
+		// Act
+		err := m.Decode(opcode)
+
+		// Assert
+		fault, ok := err.(*MemoryFaultError)
+		if assert.True(suite.T(), ok, "Memory fault "+myLogger.Uint16ToString(opcode)) {
+			assert.Equal(suite.T(), uint16(0xFFE), fault.Address, "Faulty address")
+			assert.Equal(suite.T(), opcode, fault.Opcode, "Faulty opcode")
+		}
+		assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the faulty instruction")
+	}
+}
+
+func (suite *ErrorsTestSuite) TestMemoryFault_LastByte() {
+	// Adapt
+	m := createBasicMem()
+	m.I = 0xFFF
+
+	// Act
+	err := m.Decode(0xF065)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Last byte of memory is readable")
+}
+
+func (suite *ErrorsTestSuite) TestDraw_PastTheEdge() {
+	// Adapt
+	m := createBasicMem()
+	m.V[0] = 62
+	m.V[1] = 30
+
+	// Act
+	err := m.Decode(0xD015)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
+}
+
+func TestErrorsTestSuite(t *testing.T) {
+	suite.Run(t, new(ErrorsTestSuite))
+}
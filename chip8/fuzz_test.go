@@ -0,0 +1,149 @@
+package chip8
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+)
+
+// romSize is the largest rom loaded at 0x200 in 4 KiB of memory
+const romSize = 0x1000 - 0x200
+
+// fuzzProfiles are the quirks picked by the fuzz inputs
+var fuzzProfiles = []Quirks{QuirksVIP, QuirksCHIP48, QuirksSCHIP, QuirksXOCHIP, {}}
+
+// fuzzState reads the bytes of a fuzz input, it reads zeros once they are used up
+type fuzzState []byte
+
+func (s *fuzzState) byte() byte {
+	if len(*s) == 0 {
+		return 0
+	}
+	b := (*s)[0]
+	*s = (*s)[1:]
+	return b
+}
+
+func (s *fuzzState) uint16() uint16 {
+	return uint16(s.byte())<<8 | uint16(s.byte())
+}
+
+// fuzzMemory creates a chip8 in a state made of the input bytes:
+// its quirks, registers, timers, resolution, planes and held keys
+func fuzzMemory(state fuzzState) *Memory {
+	m := NewSeededMemory(fuzzProfiles[int(state.byte())%len(fuzzProfiles)], 1)
+	for i := range m.V {
+		m.V[i] = state.byte()
+	}
+	m.I = state.uint16()
+	m.PC = state.uint16()
+	m.SP = uint16(state.byte()) % uint16(m.StackDepth()+1)
+	for i := uint16(0); i < m.SP; i++ {
+		m.CallStack[i] = state.uint16()
+	}
+	m.DelayTimer = state.byte()
+	m.SoundTimer = state.byte()
+	if m.Quirks.SuperChip {
+		m.setResolution(state.byte()&1 == 1)
+	}
+	if m.Quirks.XOChip {
+		m.Planes = state.byte() & 3
+	}
+	keypad := &Keypad{}
+	for keys, k := state.uint16(), byte(0); k < 0x10; k++ {
+		if keys&(1<<k) != 0 {
+			keypad.Press(k)
+		}
+	}
+	m.Input = keypad
+	return m
+}
+
+// checkInvariants fails the test when the chip8 is in a state
+// no instruction should lead to
+func checkInvariants(t *testing.T, m *Memory) {
+	if int(m.SP) > m.StackDepth() {
+		t.Fatalf("SP 0x%X past the stack depth %d", m.SP, m.StackDepth())
+	}
+	width, height := LowResWidth, LowResHeight
+	if m.HiRes {
+		width, height = HighResWidth, HighResHeight
+	}
+	for _, plane := range [][][]bool{m.Screen, m.Screen2} {
+		if len(plane) != width || len(plane[0]) != height {
+			t.Fatalf("%dx%d plane in a %dx%d screen", len(plane), len(plane[0]), width, height)
+		}
+	}
+}
+
+// fuzzSeeds are opcodes that reach the edges of the dispatch tables
+var fuzzSeeds = []uint16{
+	0x0000, 0x00E0, 0x00EE, 0x00C5, 0x00FD, 0x00FF, 0x0FFF, 0x2FFF, 0x5FF2, 0x5FF3,
+	0x8FF4, 0x8FFF, 0xBFFF, 0xDFFF, 0xDFF0, 0xE000, 0xEF9E, 0xF000, 0xF0FF, 0xFF0A,
+	0xFF33, 0xFF55, 0xFF65, 0xFF75, 0xFF85, 0xFFFF,
+}
+
+// FuzzDecode executes any opcode on any state, it must not panic,
+// and an opcode returning an error must leave the registers unchanged.
+// Run it with: go test ./chip8 -run '^$' -fuzz FuzzDecode
+func FuzzDecode(f *testing.F) {
+	myLogger.Discard()
+	for i, opcode := range fuzzSeeds {
+		f.Add(opcode, []byte{byte(i), 0xFF, 0x10, 0xFF})
+		f.Add(opcode, []byte{byte(i), 0x00, 0xFF, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	}
+	f.Fuzz(func(t *testing.T, opcode uint16, state []byte) {
+		m := fuzzMemory(state)
+		pc, i, sp, v := m.PC, m.I, m.SP, m.V
+
+		err := m.Decode(opcode)
+
+		checkInvariants(t, m)
+		if err != nil && (m.PC != pc || m.I != i || m.SP != sp || m.V != v) {
+			t.Fatalf("0x%04X changed the registers and failed with %v", opcode, err)
+		}
+	})
+}
+
+// FuzzRun runs any rom for 2000 cycles or until an error, it must not panic
+// nor execute an instruction out of memory.
+// Run it with: go test ./chip8 -run '^$' -fuzz FuzzRun
+func FuzzRun(f *testing.F) {
+	myLogger.Discard()
+	for i, name := range []string{"PONG", "BRIX", "INVADERS", "MAZE"} {
+		rom, err := ioutil.ReadFile("../rom/" + name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(byte(i), uint16(0x0020), rom)
+	}
+	f.Add(byte(2), uint16(0), []byte{0x00, 0xFF, 0xD0, 0x00, 0x12, 0x00})
+	f.Add(byte(3), uint16(0), []byte{0xF0, 0x00, 0xFF, 0xFE, 0xF2, 0x65})
+	f.Fuzz(func(t *testing.T, profile byte, keys uint16, rom []byte) {
+		if len(rom) > romSize {
+			rom = rom[:romSize]
+		}
+		m := fuzzMemory(fuzzState{profile, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x02, 0x00})
+		copy(m.Memory[0x200:], rom)
+		for k := byte(0); k < 0x10; k++ {
+			if keys&(1<<k) != 0 {
+				m.Input.(*Keypad).Press(k)
+			}
+		}
+
+		for cycle := 0; cycle < 2000; cycle++ {
+			pc := m.PC
+			if err := m.Iterate(); err != nil {
+				break
+			}
+			if int(pc)+2 > m.MemorySize() {
+				t.Fatalf("instruction executed at 0x%04X out of memory", pc)
+			}
+			checkInvariants(t, m)
+			if cycle%10 == 0 {
+				m.TickTimers()
+			}
+		}
+	})
+}
@@ -0,0 +1,302 @@
+// Package reference is a deliberately simple CHIP-8 interpreter used to test
+// the chip8 package against, it only knows the original instruction set.
+// It favors being obviously right over being fast or complete:
+// no SUPER-CHIP or XO-CHIP, no display or timers, one instruction at a time.
+package reference
+
+import (
+	"errors"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// Width and Height are the size of the screen, MemorySize the size of the memory
+const (
+	Width      = 64
+	Height     = 32
+	MemorySize = 4096
+)
+
+// ErrFault is returned by Step when an instruction cannot be executed:
+// an unknown opcode, a memory access past 4 KiB or a full or empty stack.
+// The machine is then left unchanged.
+var ErrFault = errors.New("reference: fault")
+
+// Machine is the state of a CHIP-8
+type Machine struct {
+	V      [16]byte
+	I      uint16
+	PC     uint16
+	Stack  []uint16
+	DT, ST byte
+	Memory [MemorySize]byte
+	// Screen is indexed by row then column
+	Screen [Height][Width]bool
+	// Keys has the bit k set while the key k is held
+	Keys uint16
+	// Quirks selects the behavior of the ambiguous instructions,
+	// the SUPER-CHIP and XO-CHIP ones are ignored
+	Quirks chip8.Quirks
+	// Random returns the random bytes of CXNN
+	Random func() byte
+
+	// waiting is set while FX0A waits for the release of waitKey
+	waiting bool
+	waitKey byte
+}
+
+// depth returns the number of nested calls allowed
+func (m *Machine) depth() int {
+	if m.Quirks.StackDepth > 0 {
+		return m.Quirks.StackDepth
+	}
+	return 256
+}
+
+// held tells if a key is held
+func (m *Machine) held(key byte) bool {
+	return m.Keys&(1<<(key&0xF)) != 0
+}
+
+// inMemory tells if the size bytes from address are in memory
+func inMemory(address uint16, size int) bool {
+	return int(address)+size <= MemorySize
+}
+
+// Step executes an opcode as if it was fetched at PC
+func (m *Machine) Step(opcode uint16) error {
+	x := opcode >> 8 & 0xF
+	y := opcode >> 4 & 0xF
+	n := opcode & 0xF
+	nn := byte(opcode)
+	nnn := opcode & 0xFFF
+	next := m.PC + 2
+
+	switch opcode >> 12 {
+	case 0x0:
+		switch opcode {
+		case 0x00E0:
+			m.Screen = [Height][Width]bool{}
+		case 0x00EE:
+			if len(m.Stack) == 0 {
+				return ErrFault
+			}
+			next = m.Stack[len(m.Stack)-1]
+			m.Stack = m.Stack[:len(m.Stack)-1]
+		}
+		// the other 0NNN are machine code routines, they do nothing
+	case 0x1:
+		next = nnn
+	case 0x2:
+		if len(m.Stack) >= m.depth() {
+			return ErrFault
+		}
+		m.Stack = append(m.Stack, m.PC+2)
+		next = nnn
+	case 0x3:
+		if m.V[x] == nn {
+			next += 2
+		}
+	case 0x4:
+		if m.V[x] != nn {
+			next += 2
+		}
+	case 0x5:
+		// the last nibble is ignored, like on the COSMAC VIP
+		if m.V[x] == m.V[y] {
+			next += 2
+		}
+	case 0x6:
+		m.V[x] = nn
+	case 0x7:
+		m.V[x] += nn
+	case 0x8:
+		if !m.arithmetic(x, y, n) {
+			return ErrFault
+		}
+	case 0x9:
+		if m.V[x] != m.V[y] {
+			next += 2
+		}
+	case 0xA:
+		m.I = nnn
+	case 0xB:
+		offset := m.V[0]
+		if m.Quirks.JumpVX {
+			offset = m.V[x]
+		}
+		next = (nnn + uint16(offset)) % 0x1000
+	case 0xC:
+		m.V[x] = nn & m.Random()
+	case 0xD:
+		if !inMemory(m.I, int(n)) {
+			return ErrFault
+		}
+		m.draw(m.V[x], m.V[y], n)
+	case 0xE:
+		switch nn {
+		case 0x9E:
+			if m.held(m.V[x]) {
+				next += 2
+			}
+		case 0xA1:
+			if !m.held(m.V[x]) {
+				next += 2
+			}
+		default:
+			return ErrFault
+		}
+	case 0xF:
+		var ok bool
+		if next, ok = m.misc(x, nn, next); !ok {
+			return ErrFault
+		}
+	}
+	m.PC = next
+	return nil
+}
+
+// arithmetic executes 8XYN, it tells if the opcode exists
+func (m *Machine) arithmetic(x, y, n uint16) bool {
+	vx, vy := m.V[x], m.V[y]
+	var result, flag byte
+	switch n {
+	case 0x0:
+		m.V[x] = vy
+		return true
+	case 0x1, 0x2, 0x3:
+		switch n {
+		case 0x1:
+			m.V[x] = vx | vy
+		case 0x2:
+			m.V[x] = vx & vy
+		case 0x3:
+			m.V[x] = vx ^ vy
+		}
+		if m.Quirks.LogicResetsVF {
+			m.V[0xF] = 0
+		}
+		return true
+	case 0x4:
+		result = vx + vy
+		if int(vx)+int(vy) > 0xFF {
+			flag = 1
+		}
+	case 0x5:
+		result = vx - vy
+		if vx >= vy {
+			flag = 1
+		}
+	case 0x7:
+		result = vy - vx
+		if vy >= vx {
+			flag = 1
+		}
+	case 0x6, 0xE:
+		source := vx
+		if m.Quirks.ShiftVY {
+			source = vy
+		}
+		if n == 0x6 {
+			result = source >> 1
+			flag = source & 1
+		} else {
+			result = source << 1
+			flag = source >> 7
+		}
+	default:
+		return false
+	}
+	// the flag is written last, it wins when X is F
+	m.V[x] = result
+	m.V[0xF] = flag
+	return true
+}
+
+// draw xors a sprite of n rows at I on the screen, VF tells if a lit pixel was erased
+func (m *Machine) draw(vx, vy byte, n uint16) {
+	startX, startY := int(vx)%Width, int(vy)%Height
+	erased := false
+	for row := 0; row < int(n); row++ {
+		sprite := m.Memory[int(m.I)+row]
+		for col := 0; col < 8; col++ {
+			if sprite&(0x80>>uint(col)) == 0 {
+				continue
+			}
+			x, y := startX+col, startY+row
+			if x >= Width || y >= Height {
+				if m.Quirks.SpriteEdge == chip8.ClipSprites {
+					continue
+				}
+				x, y = x%Width, y%Height
+			}
+			if m.Screen[y][x] {
+				erased = true
+			}
+			m.Screen[y][x] = !m.Screen[y][x]
+		}
+	}
+	m.V[0xF] = 0
+	if erased {
+		m.V[0xF] = 1
+	}
+}
+
+// misc executes FXNN and returns the next PC, it tells if the opcode exists
+func (m *Machine) misc(x uint16, nn byte, next uint16) (uint16, bool) {
+	switch nn {
+	case 0x07:
+		m.V[x] = m.DT
+	case 0x0A:
+		// the key is stored once released, until then FX0A is executed again
+		if !m.waiting {
+			for k := byte(0); k < 16; k++ {
+				if m.held(k) {
+					m.waiting, m.waitKey = true, k
+					break
+				}
+			}
+			return m.PC, true
+		}
+		if m.held(m.waitKey) {
+			return m.PC, true
+		}
+		m.waiting = false
+		m.V[x] = m.waitKey
+	case 0x15:
+		m.DT = m.V[x]
+	case 0x18:
+		m.ST = m.V[x]
+	case 0x1E:
+		m.I += uint16(m.V[x])
+	case 0x29:
+		m.I = 5 * uint16(m.V[x])
+	case 0x33:
+		if !inMemory(m.I, 3) {
+			return next, false
+		}
+		m.Memory[m.I] = m.V[x] / 100
+		m.Memory[m.I+1] = m.V[x] / 10 % 10
+		m.Memory[m.I+2] = m.V[x] % 10
+	case 0x55, 0x65:
+		if !inMemory(m.I, int(x)+1) {
+			return next, false
+		}
+		for i := uint16(0); i <= x; i++ {
+			if nn == 0x55 {
+				m.Memory[m.I+i] = m.V[i]
+			} else {
+				m.V[i] = m.Memory[m.I+i]
+			}
+		}
+		switch m.Quirks.LoadStoreIndex {
+		case chip8.IndexPlusXPlusOne:
+			m.I += x + 1
+		case chip8.IndexPlusX:
+			m.I += x
+		}
+	default:
+		return next, false
+	}
+	return next, true
+}
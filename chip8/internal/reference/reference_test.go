@@ -0,0 +1,113 @@
+package reference
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReferenceTestSuite struct {
+	suite.Suite
+}
+
+// run steps through the opcodes and returns the first error
+func run(m *Machine, opcodes ...uint16) error {
+	for _, opcode := range opcodes {
+		if err := m.Step(opcode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (suite *ReferenceTestSuite) TestArithmetic() {
+	// Adapt
+	m := &Machine{PC: 0x200, Quirks: chip8.QuirksVIP}
+
+	// Act
+	err := run(m, 0x60F0, 0x6120, 0x8014, 0x6205, 0x8125)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Valid opcodes")
+	assert.Equal(suite.T(), byte(0x10), m.V[0], "Wrapped sum")
+	assert.Equal(suite.T(), byte(0x1B), m.V[1], "Difference")
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "No borrow flag")
+	assert.Equal(suite.T(), uint16(0x20A), m.PC, "PC after 5 opcodes")
+}
+
+func (suite *ReferenceTestSuite) TestCallReturn() {
+	// Adapt
+	m := &Machine{PC: 0x200, Quirks: chip8.QuirksVIP}
+
+	// Act
+	call := m.Step(0x2300)
+	ret := m.Step(0x00EE)
+	underflow := m.Step(0x00EE)
+
+	// Assert
+	assert.Nil(suite.T(), call, "Call")
+	assert.Nil(suite.T(), ret, "Return")
+	assert.Equal(suite.T(), ErrFault, underflow, "Empty stack")
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Back after the call")
+}
+
+func (suite *ReferenceTestSuite) TestDraw() {
+	// Adapt
+	m := &Machine{PC: 0x200, I: 0x300, Quirks: chip8.QuirksVIP}
+	m.Memory[0x300] = 0xC0
+	m.V[0] = 62
+
+	// Act
+	first := m.Step(0xD011)
+	collision := m.V[0xF]
+	m.Quirks.SpriteEdge = chip8.WrapSprites
+	m.V[0] = 63
+	second := m.Step(0xD011)
+
+	// Assert
+	assert.Nil(suite.T(), first, "Draw")
+	assert.Nil(suite.T(), second, "Draw")
+	assert.Equal(suite.T(), byte(0), collision, "No collision")
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "Collision")
+	assert.True(suite.T(), m.Screen[0][62], "Untouched pixel")
+	assert.False(suite.T(), m.Screen[0][63], "Erased pixel")
+	assert.True(suite.T(), m.Screen[0][0], "Wrapped pixel")
+}
+
+func (suite *ReferenceTestSuite) TestFault() {
+	// Adapt
+	m := &Machine{PC: 0x200, I: 0xFFE, Quirks: chip8.QuirksVIP}
+	m.V[0] = 7
+
+	// Act
+	errs := []error{m.Step(0xF033), m.Step(0x800F), m.Step(0xE0FF), m.Step(0xF0FF)}
+
+	// Assert
+	for _, err := range errs {
+		assert.Equal(suite.T(), ErrFault, err, "Fault")
+	}
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "PC unchanged")
+	assert.Equal(suite.T(), uint16(0xFFE), m.I, "I unchanged")
+}
+
+func (suite *ReferenceTestSuite) TestWaitKey() {
+	// Adapt
+	m := &Machine{PC: 0x200, Keys: 1 << 7}
+
+	// Act
+	run(m, 0xF30A, 0xF30A)
+	held := m.PC
+	m.Keys = 0
+	m.Step(0xF30A)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x200), held, "Waits while the key is held")
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Continues once released")
+	assert.Equal(suite.T(), byte(7), m.V[3], "Released key")
+}
+
+func TestReferenceTestSuite(t *testing.T) {
+	suite.Run(t, new(ReferenceTestSuite))
+}
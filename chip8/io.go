@@ -0,0 +1,46 @@
+package chip8
+
+// Display is a framebuffer sink,
+// it receives the screen every time the chip8 draws on it
+type Display interface {
+	Draw(screen [][]bool)
+}
+
+// PlaneDisplay is a Display that can show the two XO-CHIP bit planes,
+// each pixel has one of four colors made of its bit in each plane
+type PlaneDisplay interface {
+	Display
+	DrawPlanes(plane1, plane2 [][]bool)
+}
+
+// Input is a keypad source, it reports the state of the 16 keys
+// without blocking. Keypad is the usual implementation.
+type Input interface {
+	IsPressed(key byte) bool
+}
+
+// Watcher is told of the memory read and written by the instructions,
+// the fetch of the instructions themselves is not reported
+type Watcher interface {
+	Access(address uint16, size uint16, write bool)
+}
+
+// access reports a memory access to the Watcher if there is one
+func (m *Memory) access(address uint16, size uint16, write bool) {
+	if m.Watcher != nil {
+		m.Watcher.Access(address, size, write)
+	}
+}
+
+// refreshDisplay send the screen to the Display if there is one,
+// both planes are sent if it is a PlaneDisplay
+func (m *Memory) refreshDisplay() {
+	if m.Display == nil {
+		return
+	}
+	if d, ok := m.Display.(PlaneDisplay); ok {
+		d.DrawPlanes(m.Screen, m.Screen2)
+		return
+	}
+	m.Display.Draw(m.Screen)
+}
@@ -0,0 +1,141 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type IOTestSuite struct {
+	suite.Suite
+}
+
+type fakeDisplay struct {
+	draws  int
+	screen [][]bool
+}
+
+func (d *fakeDisplay) Draw(screen [][]bool) {
+	d.draws++
+	d.screen = screen
+}
+
+type access struct {
+	address, size uint16
+	write         bool
+}
+
+type fakeWatcher struct {
+	accesses []access
+}
+
+func (w *fakeWatcher) Access(address uint16, size uint16, write bool) {
+	w.accesses = append(w.accesses, access{address, size, write})
+}
+
+func (suite *IOTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *IOTestSuite) TestDraw_CallsDisplay() {
+	// Adapt
+	m := createBasicMem()
+	d := &fakeDisplay{}
+	m.Display = d
+
+	// Act
+	m.Decode(0xD015)
+
+	// Assert
+	assert.Equal(suite.T(), 1, d.draws, "Display refreshed")
+	assert.True(suite.T(), d.screen[0][0], "Screen sent to the display")
+}
+
+func (suite *IOTestSuite) TestClearScreen_CallsDisplay() {
+	// Adapt
+	m := createBasicMem()
+	d := &fakeDisplay{}
+	m.Display = d
+
+	// Act
+	m.Decode(0x00E0)
+
+	// Assert
+	assert.Equal(suite.T(), 1, d.draws, "Display refreshed")
+}
+
+func (suite *IOTestSuite) TestNoDisplay() {
+	// Adapt
+	m := createBasicMem()
+
+	// Act
+	m.Decode(0xD015)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[0][0], "Draw without a display")
+}
+
+func (suite *IOTestSuite) TestInput_KeyPressed() {
+	// Adapt
+	m := createBasicMem()
+	m.Input = createKeypad(0xB)
+	m.V[3] = 0xB
+
+	// Act
+	m.Decode(0xE39E)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x204), m.PC, "Skip the next instruction")
+}
+
+func (suite *IOTestSuite) TestInput_NoInput() {
+	// Adapt
+	m := createBasicMem()
+	m.V[3] = 0xB
+
+	// Act
+	m.Decode(0xE3A1)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x204), m.PC, "Skip the next instruction")
+}
+
+func (suite *IOTestSuite) TestWatcher_Accesses() {
+	// Adapt
+	m := createBasicMem()
+	w := &fakeWatcher{}
+	m.Watcher = w
+	m.I = 0x300
+
+	// Act
+	m.Decode(0xD015)
+	m.Decode(0xF233)
+	m.Decode(0xF355)
+	m.Decode(0xF165)
+	m.Decode(0x6000)
+
+	// Assert
+	assert.Equal(suite.T(), []access{
+		{0x300, 5, false}, {0x300, 3, true}, {0x300, 4, true}, {0x300, 2, false},
+	}, w.accesses, "Sprite read, BCD and registers written then read")
+}
+
+func (suite *IOTestSuite) TestWatcher_NoFetch() {
+	// Adapt
+	m := createBasicMem()
+	w := &fakeWatcher{}
+	m.Watcher = w
+	m.Memory[0x200] = 0x60
+
+	// Act
+	m.Iterate()
+
+	// Assert
+	assert.Empty(suite.T(), w.accesses, "Fetch not reported")
+}
+
+func TestIOTestSuite(t *testing.T) {
+	suite.Run(t, new(IOTestSuite))
+}
@@ -0,0 +1,63 @@
+package chip8
+
+import (
+	"sync/atomic"
+)
+
+// Keypad holds the held/released state of the 16 keys of the chip8.
+// It can be updated from another goroutine than the one calling Iterate,
+// the key opcodes only read it and never block on it.
+type Keypad struct {
+	state uint32
+}
+
+// Press marks the given key as held
+func (k *Keypad) Press(key byte) {
+	bit := uint32(1) << (key & 0x0F)
+	for {
+		old := atomic.LoadUint32(&k.state)
+		if atomic.CompareAndSwapUint32(&k.state, old, old|bit) {
+			return
+		}
+	}
+}
+
+// Release marks the given key as released
+func (k *Keypad) Release(key byte) {
+	bit := uint32(1) << (key & 0x0F)
+	for {
+		old := atomic.LoadUint32(&k.state)
+		if atomic.CompareAndSwapUint32(&k.state, old, old&^bit) {
+			return
+		}
+	}
+}
+
+// ReleaseAll marks every key as released
+func (k *Keypad) ReleaseAll() {
+	atomic.StoreUint32(&k.state, 0)
+}
+
+// IsPressed tells if the given key is held
+func (k *Keypad) IsPressed(key byte) bool {
+	return atomic.LoadUint32(&k.state)&(uint32(1)<<(key&0x0F)) != 0
+}
+
+// keyPressed tells if the given key is held on the Input of the chip8,
+// there is no key held when the chip8 has no Input
+func (m *Memory) keyPressed(key byte) bool {
+	if m.Input == nil {
+		return false
+	}
+	return m.Input.IsPressed(key & 0x0F)
+}
+
+// firstKeyPressed returns the lowest key held on the Input of the chip8
+func (m *Memory) firstKeyPressed() (bool, byte) {
+	for k := byte(0); k < 0x10; k++ {
+		if m.keyPressed(k) {
+			return true, k
+		}
+	}
+	return false, 0
+}
@@ -0,0 +1,72 @@
+package chip8
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type KeypadTestSuite struct {
+	suite.Suite
+}
+
+func (suite *KeypadTestSuite) TestPressRelease() {
+	// Adapt
+	k := &Keypad{}
+
+	// Act
+	k.Press(0x3)
+	k.Press(0xF)
+	k.Release(0x3)
+
+	// Assert
+	assert.False(suite.T(), k.IsPressed(0x3), "Key released")
+	assert.True(suite.T(), k.IsPressed(0xF), "Key still held")
+	for i := byte(0); i < 0xF; i++ {
+		assert.False(suite.T(), k.IsPressed(i), "Key never pressed")
+	}
+}
+
+func (suite *KeypadTestSuite) TestReleaseAll() {
+	// Adapt
+	k := createKeypad(1, 2, 3)
+
+	// Act
+	k.ReleaseAll()
+
+	// Assert
+	for i := byte(0); i < 0x10; i++ {
+		assert.False(suite.T(), k.IsPressed(i), "Key released")
+	}
+}
+
+func (suite *KeypadTestSuite) TestConcurrentUpdates() {
+	// Adapt
+	k := &Keypad{}
+	var wg sync.WaitGroup
+
+	// Act
+	for i := byte(0); i < 0x10; i++ {
+		wg.Add(1)
+		go func(key byte) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				k.Press(key)
+				k.Release(key)
+			}
+			k.Press(key)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for i := byte(0); i < 0x10; i++ {
+		assert.True(suite.T(), k.IsPressed(i), "No update lost")
+	}
+}
+
+func TestKeypadTestSuite(t *testing.T) {
+	suite.Run(t, new(KeypadTestSuite))
+}
@@ -1,12 +1,9 @@
 package chip8
 
 import (
-	"github.com/Oicho/GO-Chip8/graphics"
 	"github.com/Oicho/GO-Chip8/myLogger"
-	termbox "github.com/nsf/termbox-go"
 	"os"
-	"strconv"
-	"strings"
+	"time"
 )
 
 // Memory represents the internal memory of the CHIP-8 emulator
@@ -16,11 +13,49 @@ type Memory struct {
 	SP         uint16
 	DelayTimer byte
 	SoundTimer byte
-	Key        [16]bool
 	Screen     [][]bool
 	V          [16]byte
 	CallStack  [256]uint16
-	Memory     [4096]byte
+	// Memory is big enough for the XO-CHIP, only the first 4 KiB
+	// are addressable otherwise, see MemorySize
+	Memory [0x10000]byte
+	// Screen2 is the second bit plane of the XO-CHIP
+	Screen2 [][]bool
+	// Planes is the mask of the planes drawn on by the display opcodes,
+	// bit 0 is Screen and bit 1 is Screen2
+	Planes byte
+	// AudioPattern is the 1-bit XO-CHIP sound sample played by the buzzer
+	AudioPattern [16]byte
+	// Pitch sets the playback rate of AudioPattern, see PlaybackRate
+	Pitch byte
+	// HiRes is set when the SUPER-CHIP 128x64 mode is on
+	HiRes bool
+	// RPL are the SUPER-CHIP user flags of FX75 and FX85
+	RPL [16]byte
+	// Halted is set once the program exited with 00FD
+	Halted bool
+	// Rand is the random number generator of CXNN
+	Rand Random
+	// Cycles counts the instructions executed by Iterate
+	Cycles uint64
+
+	// Quirks selects the behavior of the ambiguous opcodes
+	Quirks Quirks
+
+	// Display receives the screen after each draw, it can be nil
+	Display Display
+	// Input gives the keys state to the key opcodes, it can be nil
+	Input Input
+	// Watcher is told of the memory accesses, it can be nil
+	Watcher Watcher
+
+	// waitingKey is set while FX0A holds waitKey and waits for its release
+	waitingKey bool
+	waitKey    byte
+
+	// timerClock accumulates the time not yet consumed by the timers,
+	// scaled by TimerFrequency
+	timerClock time.Duration
 }
 
 var chip8Fontset = [80]byte{
@@ -41,6 +76,35 @@ var chip8Fontset = [80]byte{
 	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
 	0xF0, 0x80, 0xF0, 0x80, 0x80} // F
 
+// bigFontAddress is where the SUPER-CHIP 8x10 font is loaded
+const bigFontAddress = 0x50
+
+var schipBigFontset = [160]byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xC0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+	0x18, 0x3C, 0x66, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+	0xFC, 0xFE, 0xC3, 0xC3, 0xFE, 0xFE, 0xC3, 0xC3, 0xFE, 0xFC, // B
+	0x3C, 0x7E, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0x7E, 0x3C, // C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFC, 0xC0, 0xC0, 0xFF, 0xFF, // E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFC, 0xC0, 0xC0, 0xC0, 0xC0} // F
+
+// Screen sizes of the low and high resolution modes
+const (
+	LowResWidth   = 64
+	LowResHeight  = 32
+	HighResWidth  = 128
+	HighResHeight = 64
+)
+
 // Init must be called when right after you create a Memory variable
 // it initialize the screen array and set some values
 func (m *Memory) Init() {
@@ -48,14 +112,51 @@ func (m *Memory) Init() {
 	for i := 0; i < 80; i++ {
 		m.Memory[i] = chip8Fontset[i]
 	}
+	copy(m.Memory[bigFontAddress:], schipBigFontset[:])
 	m.PC = 0x200
-	m.Screen = make([][]bool, 64)
-	for i := range m.Screen {
-		m.Screen[i] = make([]bool, 32)
-	}
+	m.Planes = 1
+	m.Pitch = defaultPitch
+	m.Seed(TimeSeed())
+	m.setResolution(false)
 	myLogger.Info.Print("Finished init chip8")
 }
 
+// NewMemory creates an initialized chip8 running with the given quirks,
+// its random number generator is seeded with the time
+func NewMemory(q Quirks) *Memory {
+	m := &Memory{}
+	m.Init()
+	m.Quirks = q
+	return m
+}
+
+// NewSeededMemory creates an initialized chip8 running with the given quirks
+// whose random numbers are always the same for a given seed
+func NewSeededMemory(q Quirks, seed int64) *Memory {
+	m := NewMemory(q)
+	m.Seed(seed)
+	return m
+}
+
+// Clone returns a copy of the chip8 that shares nothing with it,
+// the copy has no Display, Input or Watcher
+func (m *Memory) Clone() *Memory {
+	c := *m
+	c.Screen = clonePlane(m.Screen)
+	c.Screen2 = clonePlane(m.Screen2)
+	c.Display, c.Input, c.Watcher = nil, nil, nil
+	return &c
+}
+
+// clonePlane returns a copy of a bit plane
+func clonePlane(plane [][]bool) [][]bool {
+	c := make([][]bool, len(plane))
+	for i := range plane {
+		c[i] = append([]bool(nil), plane[i]...)
+	}
+	return c
+}
+
 // LoadRom load a rom in the memory
 func (m *Memory) LoadRom(filePath string) error {
 	myLogger.InfoPrint("Loading a ROM")
@@ -66,7 +167,7 @@ func (m *Memory) LoadRom(filePath string) error {
 	}
 
 	defer file.Close()
-	data := make([]byte, 0xE00)
+	data := make([]byte, m.MemorySize()-0x200)
 	nbBytes, err := file.Read(data)
 
 	if err != nil {
@@ -80,6 +181,15 @@ func (m *Memory) LoadRom(filePath string) error {
 	return nil
 }
 
+// MemorySize returns the size of the address space,
+// 64 KiB for the XO-CHIP and 4 KiB otherwise
+func (m *Memory) MemorySize() int {
+	if m.Quirks.XOChip {
+		return len(m.Memory)
+	}
+	return 0x1000
+}
+
 // Fetch get an opcode from memory and then return it
 func (m *Memory) Fetch() uint16 {
 	opcode := uint16(m.Memory[m.PC]) << 8
@@ -87,120 +197,26 @@ func (m *Memory) Fetch() uint16 {
 	return opcode
 }
 
-// Decode does stuff
-func (m *Memory) Decode(opcode uint16) {
-	mainFunctionArray[(0xF000&opcode)>>12](m, opcode)
+// Decode executes the given opcode.
+// It returns an *InvalidOpcodeError or a *MemoryFaultError
+// when the opcode can't be executed, the chip8 is then left unchanged.
+func (m *Memory) Decode(opcode uint16) error {
+	return mainFunctionArray[(0xF000&opcode)>>12](m, opcode)
 }
 
 // Iterate does one cycle of a chip8
-func (m *Memory) Iterate() {
-	myLogger.InfoVerbosePrint("Fetching at 0x" + myLogger.Uint16ToString(m.PC))
-	opcode := m.Fetch()
-	myLogger.InfoVerbosePrint("Executing 0x"+ myLogger.Uint16ToString(opcode))
-	m.Decode(opcode)
-}
-
-// WaitForInput wait for an input and then returns it
-func WaitForInput(m *Memory) byte {
-	for {
-		b, i := CheckInputs(m)
-		if b {
-			return i
-		}
-		// TODO sleep ?
+// and returns the error of the executed instruction if any
+func (m *Memory) Iterate() error {
+	if m.Halted {
+		return ErrHalted
 	}
-}
-
-// CheckInputs verify if there is a key pressed
-// and then set the Key  array accordingly
-var CheckInputs = func (m *Memory) (bool, byte) {
-	for i := range m.Key {
-		m.Key[i] = false
-	}
-	ev := termbox.PollEvent()
-	if ev.Type == termbox.EventKey {
-		str := string(ev.Ch)
-		if str >= "A" {
-			str = strings.ToLower(str)
-		}
-		switch str {
-		case "3":
-			m.Key[0] = true
-			return true, 0
-		case "4":
-			m.Key[1] = true
-			return true, 1
-		case "5":
-			m.Key[2] = true
-			return true, 2
-		case "6":
-			m.Key[3] = true
-			return true, 3
-		case "e":
-			m.Key[4] = true
-			return true, 4
-		case "r":
-			m.Key[5] = true
-			return true, 5
-		case "t":
-			m.Key[6] = true
-			return true, 6
-		case "y":
-			m.Key[7] = true
-			return true, 7
-		case "d":
-			m.Key[8] = true
-			return true, 8
-		case "f":
-			m.Key[9] = true
-			return true, 9
-		case "g":
-			m.Key[10] = true
-			return true, 10
-		case "h":
-			m.Key[11] = true
-			return true, 11
-		case "c":
-			m.Key[12] = true
-			return true, 12
-		case "v":
-			m.Key[13] = true
-			return true, 13
-		case "b":
-			m.Key[14] = true
-			return true, 14
-		case "n":
-			m.Key[15] = true
-			return true, 15
-		}
+	if err := m.checkAddress(0, m.PC, 2); err != nil {
+		return err
 	}
-	return false, 0
-}
-
-// PrintMemoryValues print chip8 state value
-func (m *Memory) PrintMemoryValues() {
-	graphics.PrintScreen(m.Screen)
-	height := 0
-	width := 130
-	graphics.PrintString(width,
-		height,
-		termbox.ColorDefault,
-		termbox.ColorDefault,
-		"PC="+myLogger.Uint16ToString(m.PC))
-	height++
-	graphics.PrintString(width,
-		height,
-		termbox.ColorDefault,
-		termbox.ColorDefault,
-		"I="+myLogger.Uint16ToString(m.I))
-	height++
-	for i := 0; i < 0x10; i++ {
-		graphics.PrintString(width,
-			height,
-			termbox.ColorDefault,
-			termbox.ColorDefault,
-			"V["+strconv.Itoa(i)+"]="+myLogger.ByteToString(m.V[i]))
-		height++
+	opcode := m.Fetch()
+	if err := m.Decode(opcode); err != nil {
+		return err
 	}
-
+	m.Cycles++
+	return nil
 }
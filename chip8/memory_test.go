@@ -87,6 +87,26 @@ func (suite *MemoryTestSuite) TestLoadRom_Goodfile() {
 
 }
 
+func (suite *MemoryTestSuite) TestClone() {
+	// Adapt
+	mem := createBasicMem()
+	mem.Input = &Keypad{}
+	mem.V[3] = 0x42
+	mem.Screen[1][2] = true
+
+	// Act
+	c := mem.Clone()
+	mem.V[3] = 0
+	mem.Memory[0x200] = 0xFF
+	mem.Screen[1][2] = false
+
+	// Assert
+	assert.Equal(suite.T(), byte(0x42), c.V[3], "Registers copied")
+	assert.Equal(suite.T(), byte(0), c.Memory[0x200], "Memory copied")
+	assert.True(suite.T(), c.Screen[1][2], "Screen copied")
+	assert.Nil(suite.T(), c.Input, "No input")
+}
+
 func (suite *MemoryTestSuite) TestFetch() {
 	// Adapt
 	m := createBasicMem()
@@ -13,11 +13,16 @@ type OpcodeTestSuite struct {
 	suite.Suite
 }
 
-var MockCheckInputs = CheckInputs
-
 func (suite *OpcodeTestSuite) SetupTest() {
 	myLogger.Init(true)
-	CheckInputs = MockCheckInputs
+}
+
+func createKeypad(keys ...byte) *Keypad {
+	k := &Keypad{}
+	for _, key := range keys {
+		k.Press(key)
+	}
+	return k
 }
 
 func (suite *OpcodeTestSuite) TestClearScreen() {
@@ -80,7 +85,7 @@ func (suite *OpcodeTestSuite) Test2NNN_OK() {
 
 	// Assert
 	assert.Equal(suite.T(), 1, m.SP, "Increment stack pointer")
-	assert.Equal(suite.T(), uint16(0x200), m.CallStack[0], "Return position")
+	assert.Equal(suite.T(), uint16(0x202), m.CallStack[0], "Return position")
 	assert.Equal(suite.T(), uint16(0xFFF), m.PC, "PC didn't move")
 }
 
@@ -321,9 +326,9 @@ func (suite *OpcodeTestSuite) Test8XY4_Simple_add() {
 	m.Decode(0x8234)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(5), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(2), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(0), m.V[0xF], "No carry flag")
+	assert.Equal(suite.T(), byte(5), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(2), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(0), m.V[0xF], "No carry flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
@@ -336,12 +341,24 @@ func (suite *OpcodeTestSuite) Test8XY4_Overflow() {
 	m.Decode(0x8234)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(1), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(2), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(1), m.V[0xF], "Carry flag")
+	assert.Equal(suite.T(), byte(1), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(2), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "Carry flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
+func (suite *OpcodeTestSuite) Test8XY4_VF_result() {
+	// Adapt
+	m := createBasicMem()
+	m.V[0xF] = 0xFF
+	m.V[2] = 2
+	// Act
+	m.Decode(0x8F24)
+
+	// Assert
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "Carry flag written after the result")
+}
+
 func (suite *OpcodeTestSuite) Test8XY5_Simple_sub() {
 	// Adapt
 	m := createBasicMem()
@@ -351,9 +368,9 @@ func (suite *OpcodeTestSuite) Test8XY5_Simple_sub() {
 	m.Decode(0x8235)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(3), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(2), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(0), m.V[0xF], "No carry flag")
+	assert.Equal(suite.T(), byte(3), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(2), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "No borrow flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
@@ -366,12 +383,24 @@ func (suite *OpcodeTestSuite) Test8XY5_Overflow() {
 	m.Decode(0x8235)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(0xFE), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(7), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(1), m.V[0xF], "No carry flag")
+	assert.Equal(suite.T(), byte(0xFE), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(7), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(0), m.V[0xF], "Borrow flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
+func (suite *OpcodeTestSuite) Test8XY5_VF_result() {
+	// Adapt
+	m := createBasicMem()
+	m.V[0xF] = 5
+	m.V[2] = 7
+	// Act
+	m.Decode(0x8F25)
+
+	// Assert
+	assert.Equal(suite.T(), byte(0), m.V[0xF], "Borrow flag written after the result")
+}
+
 func (suite *OpcodeTestSuite) Test8XY6_no_flag() {
 	// Adapt
 	m := createBasicMem()
@@ -437,9 +466,9 @@ func (suite *OpcodeTestSuite) Test8XY7_Simple_sub() {
 	m.Decode(0x8237)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(5), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(10), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(0), m.V[0xF], "No carry flag")
+	assert.Equal(suite.T(), byte(5), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(10), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "No borrow flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
@@ -452,9 +481,9 @@ func (suite *OpcodeTestSuite) Test8XY7_Overflow() {
 	m.Decode(0x8237)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(0xFA), m.V[0x2], "Changed VX")
-	assert.Equal(suite.T(), uint16(4), m.V[0x3], "Unchanged VY")
-	assert.Equal(suite.T(), uint16(1), m.V[0xF], "No carry flag")
+	assert.Equal(suite.T(), byte(0xFA), m.V[0x2], "Changed VX")
+	assert.Equal(suite.T(), byte(4), m.V[0x3], "Unchanged VY")
+	assert.Equal(suite.T(), byte(0), m.V[0xF], "Borrow flag")
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
 }
 
@@ -680,18 +709,17 @@ func (suite *OpcodeTestSuite) TestENNN() {
 	m := createBasicMem()
 
 	// Act
-	m.Decode(0xEFFF)
+	err := m.Decode(0xEFFF)
 
 	// Assert
-	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
+	assert.IsType(suite.T(), &InvalidOpcodeError{}, err, "Unknown opcode")
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the faulty instruction")
 }
 
 func (suite *OpcodeTestSuite) TestEX9E_Good_Key_pressed_then_Skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return true, 4
-	}
+	m.Input = createKeypad(4)
 
 	// Act
 	m.V[0xB] = 4
@@ -704,9 +732,7 @@ func (suite *OpcodeTestSuite) TestEX9E_Good_Key_pressed_then_Skip() {
 func (suite *OpcodeTestSuite) TestEX9E_No_Key_Pressed_then_no_skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return false, 4
-	}
+	m.Input = createKeypad()
 
 	// Act
 	m.V[0xB] = 4
@@ -718,9 +744,7 @@ func (suite *OpcodeTestSuite) TestEX9E_No_Key_Pressed_then_no_skip() {
 func (suite *OpcodeTestSuite) TestEX9E_Different_Key_Pressed_then_no_skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return true, 4
-	}
+	m.Input = createKeypad(4)
 
 	// Act
 	m.V[0xB] = 5
@@ -732,9 +756,7 @@ func (suite *OpcodeTestSuite) TestEX9E_Different_Key_Pressed_then_no_skip() {
 func (suite *OpcodeTestSuite) TestEXA1_No_Key_Pressed_then_skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return false, 0
-	}
+	m.Input = createKeypad()
 
 	// Act
 	m.V[0xB] = 5
@@ -746,9 +768,7 @@ func (suite *OpcodeTestSuite) TestEXA1_No_Key_Pressed_then_skip() {
 func (suite *OpcodeTestSuite) TestEXA1_Different_Key_Pressed_then_skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return true, 1
-	}
+	m.Input = createKeypad(1)
 
 	// Act
 	m.V[0xB] = 5
@@ -760,9 +780,7 @@ func (suite *OpcodeTestSuite) TestEXA1_Different_Key_Pressed_then_skip() {
 func (suite *OpcodeTestSuite) TestEXA1_Good_Key_Pressed_then_no_skip() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return true, 1
-	}
+	m.Input = createKeypad(1)
 
 	// Act
 	m.V[0xB] = 5
@@ -788,21 +806,59 @@ func (suite *OpcodeTestSuite) TestFX07() {
 func (suite *OpcodeTestSuite) TestFX0A() {
 	// Adapt
 	m := createBasicMem()
-	CheckInputs = func (m *Memory) (bool, byte){
-		return true, 4
-	}
+	k := createKeypad(4)
+	m.Input = k
 	// Act
 	m.Decode(0xF10A)
+	k.Release(4)
+	m.Decode(0xF10A)
 
 	// Assert
 	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
-	assert.Equal(suite.T(), 0, m.V[0], "Register not set")
-	assert.Equal(suite.T(), 4, m.V[1], "Register set")
+	assert.Equal(suite.T(), byte(0), m.V[0], "Register not set")
+	assert.Equal(suite.T(), byte(4), m.V[1], "Register set")
 	for i := 2; i < 0x10; i++ {
-		assert.Equal(suite.T(), 0, m.V[i], "Register not set")
+		assert.Equal(suite.T(), byte(0), m.V[i], "Register not set")
 	}
 }
 
+func (suite *OpcodeTestSuite) TestFX0A_No_Key_then_wait() {
+	// Adapt
+	m := createBasicMem()
+	m.Input = createKeypad()
+
+	// Act
+	m.Decode(0xF10A)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the instruction")
+}
+
+func (suite *OpcodeTestSuite) TestFX0A_Key_held_then_wait_release() {
+	// Adapt
+	m := createBasicMem()
+	k := createKeypad(7)
+	m.Input = k
+
+	// Act
+	m.Decode(0xF10A)
+	m.Decode(0xF10A)
+	k.Press(9)
+	m.Decode(0xF10A)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the instruction")
+	assert.Equal(suite.T(), byte(0), m.V[1], "Register not set")
+
+	// Act
+	k.Release(7)
+	m.Decode(0xF10A)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
+	assert.Equal(suite.T(), byte(7), m.V[1], "First key stored")
+}
+
 func (suite *OpcodeTestSuite) TestFX15() {
 	// Adapt
 	m := createBasicMem()
@@ -1,342 +1,491 @@
 package chip8
 
 import (
-	"math/rand"
-
-	"github.com/Oicho/GO-Chip8/graphics"
 	"github.com/Oicho/GO-Chip8/myLogger"
 )
 
-var mainFunctionArray = [0x10]func(*Memory, uint16){ZeroDispatcher, OneJumpTo, TwoCallSubRoutine, ThreeEqSkip, FourNeqSkip, FiveEqSkip, SixSetRegister, SevenAddToRegister, EightDispatcher, NineNeqSkip, ASetAddressRegister, BJumpToV0, CSetToRandomNumber, DWrapsOnScreen, EDispatcher, FDispatcher}
-var eightFunctionArray = [0xF]func(*Memory, uint16){EightZeroSet, EightOneORSet, EightTwoANDSet, EightThreeXORSet, EightFourAdd, EightFiveSub, EightSixRightShift, EightSevenMinus, nil, nil, nil, nil, nil, nil, EightFourteenLeftShift}
-var fFunctionMap = map[uint16]func(*Memory, uint16){7: FSetVXtoDelayTimer, 0x0A: FWaitKeyPress, 0x15: FSetDelayTimerToVX, 0x18: FSetSoundTimerToVX, 0x1E: FAddVXToI, 0x29: FGoToSprite, 0x33: FBCD, 0x55: FWriteMemory, 0x65: FReadMemory}
-var r = rand.New(rand.NewSource(99))
+var mainFunctionArray = [0x10]func(*Memory, uint16) error{ZeroDispatcher, OneJumpTo, TwoCallSubRoutine, ThreeEqSkip, FourNeqSkip, FiveEqSkip, SixSetRegister, SevenAddToRegister, EightDispatcher, NineNeqSkip, ASetAddressRegister, BJumpToV0, CSetToRandomNumber, DWrapsOnScreen, EDispatcher, FDispatcher}
+var eightFunctionArray = [0xF]func(*Memory, uint16) error{EightZeroSet, EightOneORSet, EightTwoANDSet, EightThreeXORSet, EightFourAdd, EightFiveSub, EightSixRightShift, EightSevenMinus, nil, nil, nil, nil, nil, nil, EightFourteenLeftShift}
+var fFunctionMap = map[uint16]func(*Memory, uint16) error{7: FSetVXtoDelayTimer, 0x0A: FWaitKeyPress, 0x15: FSetDelayTimerToVX, 0x18: FSetSoundTimerToVX, 0x1E: FAddVXToI, 0x29: FGoToSprite, 0x33: FBCD, 0x55: FWriteMemory, 0x65: FReadMemory}
 
 // ZeroDispatcher is the 0??? opcodes dispatcher
-func ZeroDispatcher(m *Memory, opcode uint16) {
+func ZeroDispatcher(m *Memory, opcode uint16) error {
+	if m.Quirks.SuperChip {
+		if ok, err := superChipZeroDispatcher(m, opcode); ok {
+			return err
+		}
+	}
 	switch opcode {
 	case 0x00E0:
-		ZeroClearScreen(m, opcode)
+		return ZeroClearScreen(m, opcode)
 	case 0x00EE:
-		ZeroReturnFromSubRoutine(m, opcode)
+		return ZeroReturnFromSubRoutine(m, opcode)
 	default:
+		// 0NNN calls a machine code routine of the RCA 1802, ignored
 		m.PC += 2
-		// TODO show something funny
 	}
+	return nil
 }
 
 // ZeroClearScreen is 00E0 opcode
 // which clear the screen
-func ZeroClearScreen(m *Memory, opcode uint16) {
-	for i, arr := range m.Screen {
-		for j := range arr {
-			m.Screen[i][j] = false
+func ZeroClearScreen(m *Memory, opcode uint16) error {
+	for _, plane := range m.selectedPlanes() {
+		for i, arr := range plane {
+			for j := range arr {
+				plane[i][j] = false
+			}
 		}
 	}
-	graphics.PrintScreen(m.Screen)
+	m.refreshDisplay()
 	m.PC += 2
+	return nil
 }
 
 // ZeroReturnFromSubRoutine is the 00EE opcode
 // which return from a subroutine
-func ZeroReturnFromSubRoutine(m *Memory, opcode uint16) {
+func ZeroReturnFromSubRoutine(m *Memory, opcode uint16) error {
+	if m.SP == 0 {
+		return &StackUnderflowError{PC: m.PC, Stack: m.stackContent()}
+	}
 	m.SP--
 	m.PC = m.CallStack[m.SP]
+	return nil
 }
 
 // OneJumpTo is the 1NNN opcode which jump to the NNN address
-func OneJumpTo(m *Memory, opcode uint16) {
+func OneJumpTo(m *Memory, opcode uint16) error {
 	m.PC = opcode & 0x0FFF
 	myLogger.Info.Println(myLogger.Uint16ToString(m.PC) + ": Jumping to 0x" + myLogger.Uint16ToString(m.PC))
+	return nil
 }
 
 // TwoCallSubRoutine is the 2NNN opcode
-// which call the subroutine at the NNN address
-func TwoCallSubRoutine(m *Memory, opcode uint16) {
-	m.CallStack[m.SP] = m.PC
+// which call the subroutine at the NNN address,
+// the address of the next instruction is pushed for 00EE
+func TwoCallSubRoutine(m *Memory, opcode uint16) error {
+	if int(m.SP) >= m.StackDepth() {
+		return &StackOverflowError{PC: m.PC, Stack: m.stackContent()}
+	}
+	m.CallStack[m.SP] = m.PC + 2
 	m.SP++
 	myLogger.Info.Println(myLogger.Uint16ToString(m.PC) + ": Calling sub to 0x" + myLogger.Uint16ToString(opcode & 0x0FFF))
 	m.PC = opcode & 0x0FFF
+	return nil
 }
 
 // ThreeEqSkip is the 3XNN opcode
 // which skip the next instruction if VX equals NN
-func ThreeEqSkip(m *Memory, opcode uint16) {
+func ThreeEqSkip(m *Memory, opcode uint16) error {
 	vx := m.V[(opcode&0x0F00)>>8]
 	if vx == byte(opcode&0x00FF) {
-		m.PC += 4
+		m.skipNextInstruction()
 	} else {
 		m.PC += 2
 	}
+	return nil
 }
 
 // FourNeqSkip is the 4XNN opcode
 // which skips the next instruction if VX not equals NN
-func FourNeqSkip(m *Memory, opcode uint16) {
+func FourNeqSkip(m *Memory, opcode uint16) error {
 	vx := m.V[(opcode&0x0F00)>>8]
 	if vx != byte(opcode&0x00FF) {
-		m.PC += 4
+		m.skipNextInstruction()
 	} else {
 		m.PC += 2
 	}
+	return nil
 }
 
 // FiveEqSkip is the 5XY0 opcode
 // which skips the next instruction if VX equals VY.
-func FiveEqSkip(m *Memory, opcode uint16) {
+// With the XO-CHIP, it also dispatches the 5XY2 and 5XY3 opcodes.
+func FiveEqSkip(m *Memory, opcode uint16) error {
+	if m.Quirks.XOChip {
+		switch opcode & 0x000F {
+		case 0x2:
+			return FiveSaveRange(m, opcode)
+		case 0x3:
+			return FiveLoadRange(m, opcode)
+		}
+	}
 	vx := m.V[(opcode&0x0F00)>>8]
 	vy := m.V[(opcode&0x00F0)>>4]
 	if vx == vy {
-		m.PC += 4
+		m.skipNextInstruction()
 	} else {
 		m.PC += 2
 	}
+	return nil
 }
 
 // SixSetRegister is the 6XNN opcode
 // which set VX to NN
-func SixSetRegister(m *Memory, opcode uint16) {
+func SixSetRegister(m *Memory, opcode uint16) error {
 	x :=byte((opcode&0x0F00)>>8)
 	value := byte(opcode & 0x00FF)
 	m.V[x] = value
 	myLogger.Info.Println(myLogger.Uint16ToString(m.PC) + ": V[0x"+ myLogger.ByteToString(x) +"] = " + myLogger.ByteToString(value))
 	m.PC += 2
+	return nil
 }
 
 // SevenAddToRegister is the 7XNN opcode
 // which add NN to VX
-func SevenAddToRegister(m *Memory, opcode uint16) {
+func SevenAddToRegister(m *Memory, opcode uint16) error {
 	m.V[(opcode&0x0F00)>>8] += byte(opcode & 0x00FF)
 	m.PC += 2
+	return nil
 }
 
 // EightDispatcher is the dispatcher for 8XY? opcodes
-func EightDispatcher(m *Memory, opcode uint16) {
+func EightDispatcher(m *Memory, opcode uint16) error {
 	code := opcode & 0x000F
-	eightFunctionArray[code](m, opcode)
+	if int(code) >= len(eightFunctionArray) || eightFunctionArray[code] == nil {
+		return m.invalidOpcode(opcode)
+	}
+	if err := eightFunctionArray[code](m, opcode); err != nil {
+		return err
+	}
 	m.PC += 2
+	return nil
 }
 
 // -------------- 8XY? opcde-----------\\
 
 // EightZeroSet is the 8XY0 opcode
 // which sets VX to the value of VY
-func EightZeroSet(m *Memory, opcode uint16) {
+func EightZeroSet(m *Memory, opcode uint16) error {
 	m.V[(opcode&0x0F00)>>8] = m.V[(opcode&0x00F0)>>4]
+	return nil
 }
 
 // EightOneORSet is the 8XY1 opcode
 // which sets VX to VX OR VY
-func EightOneORSet(m *Memory, opcode uint16) {
+func EightOneORSet(m *Memory, opcode uint16) error {
 	m.V[(opcode&0x0F00)>>8] = m.V[(opcode&0x0F00)>>8] | m.V[(opcode&0x00F0)>>4]
+	m.logicResetVF()
+	return nil
 }
 
 // EightTwoANDSet is the 8XY2 opcode
 // which sets VX to VX AND VY
-func EightTwoANDSet(m *Memory, opcode uint16) {
+func EightTwoANDSet(m *Memory, opcode uint16) error {
 	m.V[(opcode&0x0F00)>>8] = m.V[(opcode&0x0F00)>>8] & m.V[(opcode&0x00F0)>>4]
+	m.logicResetVF()
+	return nil
 }
 
 // EightThreeXORSet is the 8XY3 opcode
 // which sets VX to VX XOR VY
-func EightThreeXORSet(m *Memory, opcode uint16) {
+func EightThreeXORSet(m *Memory, opcode uint16) error {
 	x, y := xyExtractor(opcode)
 	m.V[x] = m.V[x] ^ m.V[y]
 	sx, sy, sOpcode:= convVar(opcode, x, y)
 	myLogger.Info.Println(sOpcode + ": V[0x" + sx + "] XOR V[0x" + sy+ "] = " + myLogger.ByteToString(m.V[x]))
+	m.logicResetVF()
+	return nil
 }
 
 // EightFourAdd is the 8XY4 opcode
 // which Adds VY to VX. VF is set to 1 when there's a carry, and to 0 when there isn't
-func EightFourAdd(m *Memory, opcode uint16) {
+func EightFourAdd(m *Memory, opcode uint16) error {
 	x, y := xyExtractor(opcode)
-	if m.V[y] > 0xff-m.V[x] {
-		m.V[0xF] = 1
-	} else {
-		m.V[0xF] = 0
-	}
-	m.V[x] += m.V[y]
+	sum := uint16(m.V[x]) + uint16(m.V[y])
+	m.V[x] = byte(sum)
+	m.V[0xF] = byte(sum >> 8)
+	return nil
 }
 
 // EightFiveSub is the 8XY5 opcode
-// which set VX to VX-VY
-func EightFiveSub(m *Memory, opcode uint16) {
+// which set VX to VX-VY. VF is set to 0 when there's a borrow, and to 1 when there isn't
+func EightFiveSub(m *Memory, opcode uint16) error {
 	x, y := xyExtractor(opcode)
-	if m.V[x] < m.V[y] {
-		m.V[0xF] = 1
-	} else {
-		m.V[0xF] = 0
-	}
+	noBorrow := m.V[x] >= m.V[y]
 	m.V[x] = m.V[x] - m.V[y]
+	m.V[0xF] = flag(noBorrow)
+	return nil
 }
 
 // EightSixRightShift is the 8XY6 opcode
-// which shifts VX right by one
-func EightSixRightShift(m *Memory, opcode uint16) {
-	x := (opcode & 0x0F00) >> 8
-	m.V[0xF] = 1 & m.V[x]
-	m.V[x] = m.V[x] >> 1
+// which shifts VX right by one,
+// or stores VY shifted right by one in VX with the ShiftVY quirk
+func EightSixRightShift(m *Memory, opcode uint16) error {
+	x, y := xyExtractor(opcode)
+	src := m.V[x]
+	if m.Quirks.ShiftVY {
+		src = m.V[y]
+	}
+	m.V[x] = src >> 1
+	m.V[0xF] = 1 & src
+	return nil
 }
 
 // EightSevenMinus is the 8XY7 opcode
-// which set VX to VY-VX
-func EightSevenMinus(m *Memory, opcode uint16) {
+// which set VX to VY-VX. VF is set to 0 when there's a borrow, and to 1 when there isn't
+func EightSevenMinus(m *Memory, opcode uint16) error {
 	x, y := xyExtractor(opcode)
-	if m.V[x] > m.V[y] {
-		m.V[0xF] = 1
-	} else {
-		m.V[0xF] = 0
-	}
+	noBorrow := m.V[y] >= m.V[x]
 	m.V[x] = m.V[y] - m.V[x]
+	m.V[0xF] = flag(noBorrow)
+	return nil
 }
 
 // EightFourteenLeftShift is the 8XYE opcode
-// which shifts VX left by one
-func EightFourteenLeftShift(m *Memory, opcode uint16) {
-	x := (opcode & 0x0F00) >> 8
-	if 0x80&m.V[x] == 0 {
+// which shifts VX left by one,
+// or stores VY shifted left by one in VX with the ShiftVY quirk
+func EightFourteenLeftShift(m *Memory, opcode uint16) error {
+	x, y := xyExtractor(opcode)
+	src := m.V[x]
+	if m.Quirks.ShiftVY {
+		src = m.V[y]
+	}
+	m.V[x] = src << 1
+	if 0x80&src == 0 {
 		m.V[0xF] = 0
 	} else {
 		m.V[0xF] = 1
 	}
-	m.V[x] = m.V[x] << 1
+	return nil
 }
 
 // NineNeqSkip is the 9XY0 opcode
 // which skips the next instruction if VX doesn't equal VY
-func NineNeqSkip(m *Memory, opcode uint16) {
+func NineNeqSkip(m *Memory, opcode uint16) error {
 	if m.V[(opcode&0x0F00)>>8] != m.V[(opcode&0x00F0)>>4] {
-		m.PC += 4
+		m.skipNextInstruction()
 	} else {
 		m.PC += 2
 	}
+	return nil
 }
 
 // ASetAddressRegister is the ANNN opcode
 // which set the Address register I to NNN
-func ASetAddressRegister(m *Memory, opcode uint16) {
+func ASetAddressRegister(m *Memory, opcode uint16) error {
 	m.I = opcode & 0x0FFF
 	m.PC += 2
 	sOpcode := myLogger.Uint16ToString(opcode)
 	sI := myLogger.Uint16ToString(m.I)
 	myLogger.Info.Println(sOpcode + ": set I = " + sI)
+	return nil
 }
 
 // BJumpToV0 is the BNNN opcode
-// which jump to the address V0 + NNN
-func BJumpToV0(m *Memory, opcode uint16) {
-	m.PC = uint16(m.V[0]) + (opcode & 0x0FFF)
+// which jump to the address V0 + NNN,
+// or to the address VX + XNN with the JumpVX quirk
+func BJumpToV0(m *Memory, opcode uint16) error {
+	offset := m.V[0]
+	if m.Quirks.JumpVX {
+		offset = m.V[(opcode&0x0F00)>>8]
+	}
+	m.PC = uint16(offset) + (opcode & 0x0FFF)
 	if m.PC >= 0x1000 {
 		m.PC = m.PC - 0x1000
 	}
+	return nil
 }
 
 // CSetToRandomNumber is the CXNN opcode
 // which set VX to a random number and NN
-func CSetToRandomNumber(m *Memory, opcode uint16) {
-	m.V[(opcode&0x0F00)>>8] = byte((opcode & 0x00FF)) & byte(r.Int63n(0x100))
+func CSetToRandomNumber(m *Memory, opcode uint16) error {
+	m.V[(opcode&0x0F00)>>8] = byte((opcode & 0x00FF)) & m.Rand.Byte()
 	m.PC += 2
+	return nil
 }
 
 // DWrapsOnScreen is the DXYN opcode
-// which draw sprites
-func DWrapsOnScreen(m *Memory, opcode uint16) {
+// which draw sprites.
+// The start coordinate always wraps around the screen, the pixels going
+// past the edge are clipped or wrapped depending on the SpriteEdge quirk.
+// With the SUPER-CHIP, DXY0 draws a 16x16 sprite.
+// With the XO-CHIP, the sprite is drawn on each selected plane,
+// the data for the second plane follows the data for the first one.
+func DWrapsOnScreen(m *Memory, opcode uint16) error {
 	x, y := xyExtractor(opcode)
-	vx := uint16(m.V[x])
-	vy := uint16(m.V[y])
-	height := 0x000F & opcode
-	m.V[0xF] = 0
-	for py := uint16(0); py < height; py++ {
-		pixel := m.Memory[m.I+py]
-		for px := uint16(0); px < 8; px++ {
-			if (pixel & (0x80 >> px)) != 0 {
-				if m.Screen[px+vx][py+vy] {
-					m.V[0xF] = 1
+	rows := 0x000F & opcode
+	columns := uint16(8)
+	if rows == 0 && m.Quirks.SuperChip {
+		rows, columns = 16, 16
+	}
+	size := rows * columns / 8
+	planes := m.selectedPlanes()
+	if err := m.checkAddress(opcode, m.I, size*uint16(len(planes))); err != nil {
+		return err
+	}
+	m.access(m.I, size*uint16(len(planes)), false)
+	collided, clipped := 0, 0
+	for i, plane := range planes {
+		c, cl := m.drawSprite(plane, m.I+uint16(i)*size, m.V[x], m.V[y], rows, columns)
+		collided += c
+		clipped = cl
+	}
+	if m.Quirks.SuperChip && !m.Quirks.XOChip && m.HiRes {
+		// the SUPER-CHIP counts the colliding and the clipped rows in hires
+		m.V[0xF] = byte(collided + clipped)
+	} else if collided > 0 {
+		m.V[0xF] = 1
+	} else {
+		m.V[0xF] = 0
+	}
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// drawSprite xors the sprite at address on the plane at VX, VY
+// and returns the number of rows that collided and that were clipped at the bottom
+func (m *Memory) drawSprite(plane [][]bool, address uint16, vx, vy byte, rows, columns uint16) (collided int, clipped int) {
+	width := uint16(len(plane))
+	height := uint16(len(plane[0]))
+	startX := uint16(vx) % width
+	startY := uint16(vy) % height
+	rowBytes := columns / 8
+	for py := uint16(0); py < rows; py++ {
+		sy := startY + py
+		if sy >= height {
+			if m.Quirks.SpriteEdge == ClipSprites {
+				clipped = int(rows - py)
+				break
+			}
+			sy %= height
+		}
+		var pixels uint16
+		for b := uint16(0); b < rowBytes; b++ {
+			pixels = pixels<<8 | uint16(m.Memory[address+py*rowBytes+b])
+		}
+		collision := false
+		for px := uint16(0); px < columns; px++ {
+			sx := startX + px
+			if sx >= width {
+				if m.Quirks.SpriteEdge == ClipSprites {
+					break
+				}
+				sx %= width
+			}
+			if pixels&(1<<(columns-1-px)) != 0 {
+				if plane[sx][sy] {
+					collision = true
 				}
-				m.Screen[px+vx][py+vy] = !m.Screen[px+vx][py+vy]
+				plane[sx][sy] = !plane[sx][sy]
 			}
 		}
+		if collision {
+			collided++
+		}
 	}
-	graphics.PrintScreen(m.Screen)
-	m.PC += 2
+	return collided, clipped
 }
 
 // EDispatcher is the E??? opcodes dispatcher
-func EDispatcher(m *Memory, opcode uint16) {
+func EDispatcher(m *Memory, opcode uint16) error {
 	switch opcode & 0x00FF {
 	case 0x9E:
 		ESkipIfKeyPress(m, opcode)
 	case 0xA1:
 		ESkipIfKeyNotPress(m, opcode)
 	default:
-		// TODO show error
+		return m.invalidOpcode(opcode)
 	}
 	m.PC += 2
+	return nil
 }
 
 // ESkipIfKeyPress is the EX9E opcode
 // which skip the next instruction if the key stored in VX is pressed
-func ESkipIfKeyPress(m *Memory, opcode uint16) {
-	b, k := CheckInputs(m)
-
-	if b && k == m.V[(opcode&0x0F00)>>8] {
-		m.PC += 2
+func ESkipIfKeyPress(m *Memory, opcode uint16) error {
+	if m.keyPressed(m.V[(opcode&0x0F00)>>8]) {
+		m.PC += m.instructionSize(m.PC + 2)
 	}
+	return nil
 }
 
 // ESkipIfKeyNotPress is the EXA1 opcode
 // which skip the next instruction if the key stored in VX is not pressed
-func ESkipIfKeyNotPress(m *Memory, opcode uint16) {
-	b, k := CheckInputs(m)
-
-	if !b || k != m.V[(opcode&0x0F00)>>8] {
-		m.PC += 2
+func ESkipIfKeyNotPress(m *Memory, opcode uint16) error {
+	if !m.keyPressed(m.V[(opcode&0x0F00)>>8]) {
+		m.PC += m.instructionSize(m.PC + 2)
 	}
+	return nil
 }
 
 // FDispatcher is the dispatcher for FNNN opcodes
-func FDispatcher(m *Memory, opcode uint16) {
-	fFunctionMap[opcode&0x00FF](m, opcode)
+func FDispatcher(m *Memory, opcode uint16) error {
+	if opcode == 0xF000 && m.Quirks.XOChip {
+		return FLongSetAddressRegister(m, opcode)
+	}
+	f, ok := fFunctionMap[opcode&0x00FF]
+	if !ok && m.Quirks.SuperChip {
+		f, ok = fSuperChipFunctionMap[opcode&0x00FF]
+	}
+	if !ok && m.Quirks.XOChip {
+		f, ok = fXOChipFunctionMap[opcode&0x00FF]
+	}
+	if !ok {
+		return m.invalidOpcode(opcode)
+	}
+	if err := f(m, opcode); err != nil {
+		return err
+	}
 	m.PC += 2
+	return nil
 }
 
 // FSetVXtoDelayTimer is the FX07 opcode
 // which sets VX to the value of the delay timer
-func FSetVXtoDelayTimer(m *Memory, opcode uint16) {
+func FSetVXtoDelayTimer(m *Memory, opcode uint16) error {
 	m.V[(opcode&0x0F00)>>8] = m.DelayTimer
+	return nil
 }
 
 // FWaitKeyPress is the FX0A opcode
-// which wait a key press and then stores it in VX
-func FWaitKeyPress(m *Memory, opcode uint16) {
-	m.V[(opcode&0x0F00)>>8] = WaitForInput(m)
+// which wait a key press and then stores it in VX.
+// Like on the COSMAC VIP, the key is only stored once it is released.
+// The opcode never blocks, it is executed again until the key is released.
+func FWaitKeyPress(m *Memory, opcode uint16) error {
+	if !m.waitingKey {
+		m.waitingKey, m.waitKey = m.firstKeyPressed()
+		m.PC -= 2
+		return nil
+	}
+	if m.keyPressed(m.waitKey) {
+		m.PC -= 2
+		return nil
+	}
+	m.waitingKey = false
+	m.V[(opcode&0x0F00)>>8] = m.waitKey
+	return nil
 }
 
 // FSetDelayTimerToVX is the FX15 opcode
 // which sets the delay timer to VX
-func FSetDelayTimerToVX(m *Memory, opcode uint16) {
+func FSetDelayTimerToVX(m *Memory, opcode uint16) error {
 	m.DelayTimer = m.V[(opcode&0x0F00)>>8]
+	return nil
 }
 
 // FSetSoundTimerToVX is the FX18 opcode
 // which sets the sound timer to VX
-func FSetSoundTimerToVX(m *Memory, opcode uint16) {
+func FSetSoundTimerToVX(m *Memory, opcode uint16) error {
 	m.SoundTimer = m.V[(opcode&0x0F00)>>8]
+	return nil
 }
 
 // FAddVXToI is the FX1E opcode
 // which adds VX to I
-func FAddVXToI(m *Memory, opcode uint16) {
+func FAddVXToI(m *Memory, opcode uint16) error {
 	m.I += uint16(m.V[(opcode&0x0F00)>>8])
 	sPC, val, sI := convVar(m.PC, uint16(m.V[(opcode&0x0F00)>>8]), m.I)
 	myLogger.Info.Println(sPC + ": adding "+ val +" to  I = "+ sI)
-
+	return nil
 }
 
 // FGoToSprite is the FX29 opcode
 // which sets I to the location of the sprite for the character in VX
-func FGoToSprite(m *Memory, opcode uint16) {
+func FGoToSprite(m *Memory, opcode uint16) error {
 	m.I = uint16(5) * uint16(m.V[(0x0F00&opcode)>>8])
+	return nil
 }
 
 // FBCD is the FX33 opcode
@@ -344,29 +493,79 @@ func FGoToSprite(m *Memory, opcode uint16) {
 // with the most significant of three digits at the address in I,
 // the middle digit at I plus 1,
 // and the least significant digit at I plus 2.
-func FBCD(m *Memory, opcode uint16) {
+func FBCD(m *Memory, opcode uint16) error {
+	if err := m.checkAddress(opcode, m.I, 3); err != nil {
+		return err
+	}
+	m.access(m.I, 3, true)
 	vx := m.V[(0x0F00&opcode)>>8]
 	m.Memory[m.I] = vx / 100
 	m.Memory[m.I+1] = (vx / 10) % 10
 	m.Memory[m.I+2] = (vx % 100) % 10
+	return nil
 }
 
 // FWriteMemory is the FX55 opcode
 // which stores V0 to VX in memory starting at address I
-func FWriteMemory(m *Memory, opcode uint16) {
+func FWriteMemory(m *Memory, opcode uint16) error {
 	vx := (opcode & 0x0F00) >> 8
+	if err := m.checkAddress(opcode, m.I, vx+1); err != nil {
+		return err
+	}
+	m.access(m.I, vx+1, true)
 	for p := uint16(0); p <= vx; p++ {
 		m.Memory[m.I+p] = m.V[p]
 	}
+	m.loadStoreMoveI(vx)
+	return nil
 }
 
 // FReadMemory is the FX65 opcode
 // which fills V0 to VX with values from memory starting at address I
-func FReadMemory(m *Memory, opcode uint16) {
+func FReadMemory(m *Memory, opcode uint16) error {
 	vx := (opcode & 0x0F00) >> 8
+	if err := m.checkAddress(opcode, m.I, vx+1); err != nil {
+		return err
+	}
+	m.access(m.I, vx+1, false)
 	for p := uint16(0); p <= vx; p++ {
 		m.V[p] = m.Memory[m.I+p]
 	}
+	m.loadStoreMoveI(vx)
+	return nil
+}
+
+// logicResetVF clears VF after 8XY1, 8XY2 and 8XY3 with the LogicResetsVF quirk
+func (m *Memory) logicResetVF() {
+	if m.Quirks.LogicResetsVF {
+		m.V[0xF] = 0
+	}
+}
+
+// loadStoreMoveI moves I after FX55 and FX65 according to the quirks
+func (m *Memory) loadStoreMoveI(x uint16) {
+	switch m.Quirks.LoadStoreIndex {
+	case IndexPlusXPlusOne:
+		m.I += x + 1
+	case IndexPlusX:
+		m.I += x
+	}
+}
+
+// instructionSize returns the size in bytes of the instruction at address,
+// only the XO-CHIP F000 NNNN instruction is 4 bytes long
+func (m *Memory) instructionSize(address uint16) uint16 {
+	if m.Quirks.XOChip && int(address)+1 < len(m.Memory) &&
+		m.Memory[address] == 0xF0 && m.Memory[address+1] == 0x00 {
+		return 4
+	}
+	return 2
+}
+
+// skipNextInstruction moves PC after the instruction following the current one
+func (m *Memory) skipNextInstruction() {
+	m.PC += 2
+	m.PC += m.instructionSize(m.PC)
 }
 
 func xyExtractor(opcode uint16) (x uint16, y uint16) {
@@ -375,6 +574,14 @@ func xyExtractor(opcode uint16) (x uint16, y uint16) {
 	return
 }
 
+// flag returns the VF value of a condition, 1 when it holds
+func flag(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func convVar(PC uint16, x uint16, y uint16) (string, string, string){
 	sx := myLogger.Uint16ToString(x)
 	sy := myLogger.Uint16ToString(y)
@@ -0,0 +1,118 @@
+package chip8
+
+import (
+	"sort"
+)
+
+// IndexIncrement is how FX55 and FX65 move I after the transfer
+type IndexIncrement int
+
+const (
+	// IndexUnchanged leaves I untouched, like the SUPER-CHIP
+	IndexUnchanged IndexIncrement = iota
+	// IndexPlusXPlusOne leaves I after the last byte, like the COSMAC VIP
+	IndexPlusXPlusOne
+	// IndexPlusX leaves I on the last byte, like the CHIP-48
+	IndexPlusX
+)
+
+// Quirks selects the interpretation of the opcodes
+// that behave differently between the CHIP-8 platforms.
+// The zero value is the historical behavior of this emulator.
+type Quirks struct {
+	// LogicResetsVF makes 8XY1, 8XY2 and 8XY3 set VF to 0
+	LogicResetsVF bool
+	// ShiftVY makes 8XY6 and 8XYE shift VY and store the result in VX,
+	// instead of shifting VX in place
+	ShiftVY bool
+	// LoadStoreIndex is how FX55 and FX65 move I
+	LoadStoreIndex IndexIncrement
+	// JumpVX makes BXNN jump to XNN + VX instead of NNN + V0
+	JumpVX bool
+	// SpriteEdge tells DXYN to clip or wrap the pixels past the screen edge
+	SpriteEdge SpriteEdge
+	// StackDepth is the number of nested 2NNN calls,
+	// 0 means as many as the CallStack can hold
+	StackDepth int
+	// SuperChip enables the SUPER-CHIP 1.1 instructions:
+	// hires mode, scrolling, exit, 16x16 sprites, big font and RPL flags
+	SuperChip bool
+	// XOChip enables the XO-CHIP instructions: 64 KiB of memory,
+	// F000 NNNN, 5XY2, 5XY3, 00DN, bit planes and the audio pattern.
+	// It should be used with SuperChip.
+	XOChip bool
+}
+
+var (
+	// QuirksVIP is the behavior of the original COSMAC VIP interpreter
+	QuirksVIP = Quirks{
+		LogicResetsVF:  true,
+		ShiftVY:        true,
+		LoadStoreIndex: IndexPlusXPlusOne,
+		JumpVX:         false,
+		SpriteEdge:     ClipSprites,
+		StackDepth:     12,
+	}
+	// QuirksCHIP48 is the behavior of the CHIP-48 interpreter of the HP-48
+	QuirksCHIP48 = Quirks{
+		LogicResetsVF:  false,
+		ShiftVY:        false,
+		LoadStoreIndex: IndexPlusX,
+		JumpVX:         true,
+		SpriteEdge:     ClipSprites,
+		StackDepth:     16,
+	}
+	// QuirksSCHIP is the behavior of the SUPER-CHIP 1.1 interpreter
+	QuirksSCHIP = Quirks{
+		LogicResetsVF:  false,
+		ShiftVY:        false,
+		LoadStoreIndex: IndexUnchanged,
+		JumpVX:         true,
+		SpriteEdge:     ClipSprites,
+		StackDepth:     16,
+		SuperChip:      true,
+	}
+	// QuirksXOCHIP is the behavior of the XO-CHIP, as run by Octo
+	QuirksXOCHIP = Quirks{
+		LogicResetsVF:  false,
+		ShiftVY:        true,
+		LoadStoreIndex: IndexPlusXPlusOne,
+		JumpVX:         false,
+		SpriteEdge:     WrapSprites,
+		StackDepth:     16,
+		SuperChip:      true,
+		XOChip:         true,
+	}
+)
+
+// StackDepth returns the number of nested calls allowed by the quirks
+func (m *Memory) StackDepth() int {
+	if m.Quirks.StackDepth <= 0 || m.Quirks.StackDepth > len(m.CallStack) {
+		return len(m.CallStack)
+	}
+	return m.Quirks.StackDepth
+}
+
+// quirksPresets maps the command line names to the quirks presets
+var quirksPresets = map[string]Quirks{
+	"vip":    QuirksVIP,
+	"chip48": QuirksCHIP48,
+	"schip":  QuirksSCHIP,
+	"xochip": QuirksXOCHIP,
+}
+
+// QuirksByName returns the preset with the given name
+func QuirksByName(name string) (Quirks, bool) {
+	q, ok := quirksPresets[name]
+	return q, ok
+}
+
+// QuirksNames returns the names of the presets, sorted
+func QuirksNames() []string {
+	names := make([]string, 0, len(quirksPresets))
+	for name := range quirksPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type QuirksTestSuite struct {
+	suite.Suite
+}
+
+func (suite *QuirksTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *QuirksTestSuite) TestNewMemory() {
+	// Act
+	m := NewMemory(QuirksVIP)
+
+	// Assert
+	assert.Equal(suite.T(), QuirksVIP, m.Quirks, "Quirks set")
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Memory initialized")
+}
+
+func (suite *QuirksTestSuite) TestLogicResetsVF() {
+	for _, opcode := range []uint16{0x8011, 0x8012, 0x8013} {
+		// Adapt
+		vip := NewMemory(QuirksVIP)
+		schip := NewMemory(QuirksSCHIP)
+		vip.V[0xF], schip.V[0xF] = 0x42, 0x42
+
+		// Act
+		vip.Decode(opcode)
+		schip.Decode(opcode)
+
+		// Assert
+		assert.Equal(suite.T(), byte(0), vip.V[0xF], "VF reset")
+		assert.Equal(suite.T(), byte(0x42), schip.V[0xF], "VF untouched")
+	}
+}
+
+func (suite *QuirksTestSuite) TestShiftVY() {
+	// Adapt
+	vip := NewMemory(QuirksVIP)
+	schip := NewMemory(QuirksSCHIP)
+	vip.V[1], vip.V[2] = 0x0F, 0x81
+	schip.V[1], schip.V[2] = 0x0F, 0x81
+
+	// Act
+	vip.Decode(0x8126)
+	schip.Decode(0x8126)
+
+	// Assert
+	assert.Equal(suite.T(), byte(0x40), vip.V[1], "VY shifted into VX")
+	assert.Equal(suite.T(), byte(0x81), vip.V[2], "VY unchanged")
+	assert.Equal(suite.T(), byte(1), vip.V[0xF], "Shifted out bit of VY")
+	assert.Equal(suite.T(), byte(0x07), schip.V[1], "VX shifted")
+	assert.Equal(suite.T(), byte(1), schip.V[0xF], "Shifted out bit of VX")
+
+	// Act
+	vip.V[1], vip.V[2] = 0x0F, 0x81
+	vip.Decode(0x812E)
+
+	// Assert
+	assert.Equal(suite.T(), byte(0x02), vip.V[1], "VY shifted into VX")
+	assert.Equal(suite.T(), byte(1), vip.V[0xF], "Shifted out bit of VY")
+}
+
+func (suite *QuirksTestSuite) TestShift_FlagWinsOnVF() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.V[0xF] = 0x03
+
+	// Act
+	m.Decode(0x8FF6)
+
+	// Assert
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "Flag written after the result")
+}
+
+func (suite *QuirksTestSuite) TestLoadStoreIndex() {
+	presets := map[string]struct {
+		quirks Quirks
+		i      uint16
+	}{
+		"vip":    {QuirksVIP, 0x304},
+		"chip48": {QuirksCHIP48, 0x303},
+		"schip":  {QuirksSCHIP, 0x300},
+	}
+	for name, p := range presets {
+		for _, opcode := range []uint16{0xF355, 0xF365} {
+			// Adapt
+			m := NewMemory(p.quirks)
+			m.I = 0x300
+
+			// Act
+			m.Decode(opcode)
+
+			// Assert
+			assert.Equal(suite.T(), p.i, m.I, "I after "+myLogger.Uint16ToString(opcode)+" on "+name)
+		}
+	}
+}
+
+func (suite *QuirksTestSuite) TestJumpVX() {
+	// Adapt
+	vip := NewMemory(QuirksVIP)
+	schip := NewMemory(QuirksSCHIP)
+	vip.V[0], vip.V[2] = 0x10, 0x20
+	schip.V[0], schip.V[2] = 0x10, 0x20
+
+	// Act
+	vip.Decode(0xB234)
+	schip.Decode(0xB234)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x244), vip.PC, "Jump to NNN + V0")
+	assert.Equal(suite.T(), uint16(0x254), schip.PC, "Jump to XNN + VX")
+}
+
+func (suite *QuirksTestSuite) TestQuirksByName() {
+	// Act
+	q, ok := QuirksByName("chip48")
+	_, bad := QuirksByName("bad")
+
+	// Assert
+	assert.True(suite.T(), ok, "Known preset")
+	assert.False(suite.T(), bad, "Unknown preset")
+	assert.Equal(suite.T(), QuirksCHIP48, q, "Preset")
+	assert.Equal(suite.T(), []string{"chip48", "schip", "vip", "xochip"}, QuirksNames(), "Names")
+}
+
+func TestQuirksTestSuite(t *testing.T) {
+	suite.Run(t, new(QuirksTestSuite))
+}
@@ -0,0 +1,40 @@
+package chip8
+
+import (
+	"time"
+)
+
+// Random is the pseudo random number generator of a chip8 (SplitMix64).
+// Its whole state is State, so copying or saving it replays the same numbers.
+type Random struct {
+	State uint64
+}
+
+// Seed restarts the generator from the given seed
+func (r *Random) Seed(seed int64) {
+	r.State = uint64(seed)
+}
+
+// Uint64 returns the next pseudo random number
+func (r *Random) Uint64() uint64 {
+	r.State += 0x9E3779B97F4A7C15
+	z := r.State
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Byte returns the next pseudo random byte
+func (r *Random) Byte() byte {
+	return byte(r.Uint64() >> 56)
+}
+
+// TimeSeed returns a seed based on the current time
+func TimeSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// Seed restarts the random number generator of the chip8 from the given seed
+func (m *Memory) Seed(seed int64) {
+	m.Rand.Seed(seed)
+}
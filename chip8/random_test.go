@@ -0,0 +1,112 @@
+package chip8
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RandomTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RandomTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// randomBytes runs CXFF n times and returns the random bytes
+func randomBytes(m *Memory, n int) []byte {
+	res := make([]byte, n)
+	for i := range res {
+		m.Decode(0xC0FF)
+		res[i] = m.V[0]
+	}
+	return res
+}
+
+func (suite *RandomTestSuite) TestSameSeed() {
+	// Adapt
+	m1 := NewSeededMemory(QuirksVIP, 42)
+	m2 := NewSeededMemory(QuirksVIP, 42)
+
+	// Act
+	r1 := randomBytes(m1, 32)
+	r2 := randomBytes(m2, 32)
+
+	// Assert
+	assert.Equal(suite.T(), r1, r2, "Same seed, same numbers")
+}
+
+func (suite *RandomTestSuite) TestDifferentSeeds() {
+	// Adapt
+	m1 := NewSeededMemory(QuirksVIP, 1)
+	m2 := NewSeededMemory(QuirksVIP, 2)
+
+	// Act
+	r1 := randomBytes(m1, 32)
+	r2 := randomBytes(m2, 32)
+
+	// Assert
+	assert.NotEqual(suite.T(), r1, r2, "Different seeds, different numbers")
+}
+
+func (suite *RandomTestSuite) TestStateCopy() {
+	// Adapt
+	m := NewSeededMemory(QuirksVIP, 7)
+	randomBytes(m, 5)
+	saved := m.Rand
+
+	// Act
+	r1 := randomBytes(m, 8)
+	m.Rand = saved
+	r2 := randomBytes(m, 8)
+
+	// Assert
+	assert.Equal(suite.T(), r1, r2, "Restored state replays the numbers")
+}
+
+func (suite *RandomTestSuite) TestIndependentInstances() {
+	// Adapt
+	expected := randomBytes(NewSeededMemory(QuirksVIP, 3), 100)
+	var wg sync.WaitGroup
+	results := make([][]byte, 4)
+
+	// Act
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = randomBytes(NewSeededMemory(QuirksVIP, 3), 100)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	for _, r := range results {
+		assert.Equal(suite.T(), expected, r, "No shared generator")
+	}
+}
+
+func (suite *RandomTestSuite) TestDistribution() {
+	// Adapt
+	var r Random
+	r.Seed(123)
+	var seen [256]bool
+
+	// Act
+	for i := 0; i < 10000; i++ {
+		seen[r.Byte()] = true
+	}
+
+	// Assert
+	for i, b := range seen {
+		assert.True(suite.T(), b, "Byte "+myLogger.ByteToString(byte(i))+" generated")
+	}
+}
+
+func TestRandomTestSuite(t *testing.T) {
+	suite.Run(t, new(RandomTestSuite))
+}
@@ -0,0 +1,193 @@
+package chip8
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+)
+
+// ErrNoSnapshot is returned when there is nothing to rewind to
+var ErrNoSnapshot = errors.New("chip8: no snapshot to rewind to")
+
+// snapshot is a compressed save state of a chip8
+type snapshot struct {
+	cycles uint64
+	data   []byte
+}
+
+// Rewind is a ring buffer of compressed snapshots of a chip8,
+// the oldest snapshots are dropped to stay under the memory budget
+type Rewind struct {
+	// Interval is the number of instructions between two snapshots
+	Interval uint64
+	// Budget is the maximum size in bytes of the snapshots
+	Budget int
+
+	// ring holds count snapshots from head, oldest first
+	ring  []snapshot
+	head  int
+	count int
+	size  int
+}
+
+// NewRewind creates a Rewind taking a snapshot every interval instructions
+// and keeping at most budget bytes of snapshots
+func NewRewind(budget int, interval uint64) *Rewind {
+	if interval == 0 {
+		interval = 1
+	}
+	return &Rewind{Interval: interval, Budget: budget}
+}
+
+// Len returns the number of snapshots held
+func (r *Rewind) Len() int {
+	return r.count
+}
+
+// Size returns the size in bytes of the snapshots held
+func (r *Rewind) Size() int {
+	return r.size
+}
+
+// Clear drops every snapshot
+func (r *Rewind) Clear() {
+	r.ring = nil
+	r.head = 0
+	r.count = 0
+	r.size = 0
+}
+
+// Record takes a snapshot if Interval instructions were executed
+// since the last one, it should be called before each Iterate
+func (r *Rewind) Record(m *Memory) error {
+	if r.count > 0 && m.Cycles < r.newest().cycles+r.Interval {
+		return nil
+	}
+	return r.Snapshot(m)
+}
+
+// Snapshot takes a snapshot of the chip8 now
+func (r *Rewind) Snapshot(m *Memory) error {
+	// a snapshot of the same instruction replaces the previous one
+	if r.count > 0 && r.newest().cycles == m.Cycles {
+		r.dropNewest()
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if err := m.SaveState(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	r.push(snapshot{cycles: m.Cycles, data: buf.Bytes()})
+	for r.size > r.Budget && r.count > 1 {
+		r.dropOldest()
+	}
+	return nil
+}
+
+// Back restores the last snapshot taken before the current instruction
+// and drops the newer ones, calling it again goes further back in time
+func (r *Rewind) Back(m *Memory) error {
+	return r.BackBy(m, 1)
+}
+
+// BackBy restores the last snapshot taken at least n instructions before
+// the current one and drops the newer ones. It restores the oldest snapshot
+// when they are all more recent, and fails when none is before the current instruction.
+func (r *Rewind) BackBy(m *Memory, n uint64) error {
+	var target uint64
+	if m.Cycles > n {
+		target = m.Cycles - n
+	}
+	for r.count > 0 && r.newest().cycles >= m.Cycles {
+		r.dropNewest()
+	}
+	if r.count == 0 {
+		return ErrNoSnapshot
+	}
+	for r.count > 1 && r.newest().cycles > target {
+		r.dropNewest()
+	}
+	return r.restore(m, r.newest())
+}
+
+// StepBack brings the chip8 back one instruction. It restores the
+// closest snapshot and executes the instructions up to the previous one,
+// the timers are not ticked and the keys are read as they are now
+// while replaying. The Watcher is not told of the replayed instructions
+// and the Display only receives the screen they end on.
+func (r *Rewind) StepBack(m *Memory) error {
+	if m.Cycles == 0 {
+		return ErrNoSnapshot
+	}
+	display, watcher := m.Display, m.Watcher
+	m.Display, m.Watcher = nil, nil
+	defer func() {
+		m.Display, m.Watcher = display, watcher
+		m.refreshDisplay()
+	}()
+	target := m.Cycles - 1
+	for r.count > 0 && r.newest().cycles > target {
+		r.dropNewest()
+	}
+	if r.count == 0 {
+		return ErrNoSnapshot
+	}
+	if err := r.restore(m, r.newest()); err != nil {
+		return err
+	}
+	for m.Cycles < target {
+		if err := m.Iterate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restore loads a snapshot in the chip8
+func (r *Rewind) restore(m *Memory, snap *snapshot) error {
+	reader := flate.NewReader(bytes.NewReader(snap.data))
+	defer reader.Close()
+	return m.LoadState(reader)
+}
+
+// newest returns the last snapshot taken
+func (r *Rewind) newest() *snapshot {
+	return &r.ring[(r.head+r.count-1)%len(r.ring)]
+}
+
+// push adds a snapshot after the newest one, growing the ring if it is full
+func (r *Rewind) push(snap snapshot) {
+	if r.count == len(r.ring) {
+		ring := make([]snapshot, 2*len(r.ring)+1)
+		for i := 0; i < r.count; i++ {
+			ring[i] = r.ring[(r.head+i)%len(r.ring)]
+		}
+		r.ring = ring
+		r.head = 0
+	}
+	r.ring[(r.head+r.count)%len(r.ring)] = snap
+	r.count++
+	r.size += len(snap.data)
+}
+
+// dropOldest removes the first snapshot taken
+func (r *Rewind) dropOldest() {
+	r.size -= len(r.ring[r.head].data)
+	r.ring[r.head] = snapshot{}
+	r.head = (r.head + 1) % len(r.ring)
+	r.count--
+}
+
+// dropNewest removes the last snapshot taken
+func (r *Rewind) dropNewest() {
+	snap := r.newest()
+	r.size -= len(snap.data)
+	*snap = snapshot{}
+	r.count--
+}
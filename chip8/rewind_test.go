@@ -0,0 +1,192 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RewindTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RewindTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createRewindMem creates a chip8 running PONG
+func createRewindMem() *Memory {
+	m := NewSeededMemory(QuirksVIP, 5)
+	m.LoadRom("../rom/PONG")
+	return m
+}
+
+// runRecorded runs n instructions recording them in the rewind buffer
+func runRecorded(m *Memory, r *Rewind, n int) {
+	for i := 0; i < n; i++ {
+		r.Record(m)
+		m.Iterate()
+	}
+}
+
+func (suite *RewindTestSuite) TestRecord_Interval() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 10)
+
+	// Act
+	runRecorded(m, r, 100)
+
+	// Assert
+	assert.Equal(suite.T(), 10, r.Len(), "One snapshot every 10 instructions")
+}
+
+func (suite *RewindTestSuite) TestBudget() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(4096, 1)
+
+	// Act
+	runRecorded(m, r, 1000)
+
+	// Assert
+	assert.True(suite.T(), r.Size() <= 4096, "Budget respected")
+	assert.True(suite.T(), r.Len() > 1, "Compressed snapshots")
+	assert.True(suite.T(), r.Len() < 1000, "Oldest snapshots dropped")
+}
+
+func (suite *RewindTestSuite) TestBack() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 10)
+	runRecorded(m, r, 50)
+
+	// Act
+	err := r.Back(m)
+	err2 := r.Back(m)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Rewound")
+	assert.Nil(suite.T(), err2, "Rewound again")
+	assert.Equal(suite.T(), uint64(30), m.Cycles, "Two snapshots back")
+	assert.Equal(suite.T(), 4, r.Len(), "Newer snapshots dropped")
+}
+
+func (suite *RewindTestSuite) TestBack_Empty() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 10)
+
+	// Act
+	err := r.Back(m)
+
+	// Assert
+	assert.Equal(suite.T(), ErrNoSnapshot, err, "Nothing to rewind")
+}
+
+func (suite *RewindTestSuite) TestBackBy() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 10)
+	runRecorded(m, r, 55)
+
+	// Act
+	err := r.BackBy(m, 11)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Rewound")
+	assert.Equal(suite.T(), uint64(40), m.Cycles, "Snapshot at least 11 instructions back")
+	assert.Equal(suite.T(), 5, r.Len(), "Newer snapshots dropped")
+}
+
+func (suite *RewindTestSuite) TestBackBy_Oldest() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 10)
+	runRecorded(m, r, 25)
+
+	// Act
+	err := r.BackBy(m, 100)
+	err2 := r.BackBy(m, 100)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Rewound as far as possible")
+	assert.Equal(suite.T(), uint64(0), m.Cycles, "Oldest snapshot")
+	assert.Equal(suite.T(), ErrNoSnapshot, err2, "Nothing before the oldest snapshot")
+}
+
+func (suite *RewindTestSuite) TestStepBack() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 16)
+	reference := createRewindMem()
+	for i := 0; i < 36; i++ {
+		reference.Iterate()
+	}
+	runRecorded(m, r, 37)
+
+	// Act
+	err := r.StepBack(m)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Stepped back")
+	assert.Equal(suite.T(), reference, m, "Same state as one instruction before")
+}
+
+func (suite *RewindTestSuite) TestStepBack_Replay() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 1000)
+	runRecorded(m, r, 37)
+	display, watcher := &fakeDisplay{}, &fakeWatcher{}
+	m.Display, m.Watcher = display, watcher
+
+	// Act
+	err := r.StepBack(m)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Stepped back")
+	assert.Empty(suite.T(), watcher.accesses, "Replayed accesses not watched")
+	assert.Equal(suite.T(), 1, display.draws, "Only the screen after the replay")
+	assert.Equal(suite.T(), m.Screen, display.screen, "Screen of the previous instruction")
+	assert.Equal(suite.T(), watcher, m.Watcher, "Watcher attached again")
+}
+
+func (suite *RewindTestSuite) TestStepBack_Repeated() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(1<<20, 4)
+	runRecorded(m, r, 20)
+
+	// Act
+	for i := 0; i < 5; i++ {
+		r.StepBack(m)
+	}
+
+	// Assert
+	assert.Equal(suite.T(), uint64(15), m.Cycles, "Five instructions back")
+}
+
+func (suite *RewindTestSuite) TestRing_Wraps() {
+	// Adapt
+	m := createRewindMem()
+	r := NewRewind(3000, 1)
+	runRecorded(m, r, 300)
+	cycles := m.Cycles
+
+	// Act
+	for r.Back(m) == nil {
+	}
+
+	// Assert
+	assert.True(suite.T(), m.Cycles < cycles, "Went back in time")
+	assert.True(suite.T(), m.Cycles > 0, "Oldest snapshots dropped")
+	assert.Equal(suite.T(), 0, r.Len(), "All snapshots used")
+	assert.Equal(suite.T(), 0, r.Size(), "Size accounted")
+}
+
+func TestRewindTestSuite(t *testing.T) {
+	suite.Run(t, new(RewindTestSuite))
+}
@@ -0,0 +1,284 @@
+package chip8
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+)
+
+// StateVersion is the version of the save states written by SaveState
+const StateVersion = 1
+
+// stateMagic starts every save state
+var stateMagic = [4]byte{'C', '8', 'S', 'T'}
+
+// ErrNotAState is returned when restoring something that is not a save state
+var ErrNotAState = errors.New("chip8: not a save state")
+
+// StateVersionError is returned when restoring a save state
+// written by an unknown version of the format
+type StateVersionError struct {
+	Version uint16
+}
+
+func (e *StateVersionError) Error() string {
+	return "chip8: unsupported save state version " + strconv.Itoa(int(e.Version))
+}
+
+// StateRegisterError is returned when restoring a save state
+// whose register would put the machine out of its memory or stack
+type StateRegisterError struct {
+	Register string
+	Value    int
+}
+
+func (e *StateRegisterError) Error() string {
+	return "chip8: save state with an invalid " + e.Register + " " + strconv.Itoa(e.Value)
+}
+
+// stateHeader starts a save state
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+// stateQuirks is the fixed size encoding of Quirks
+type stateQuirks struct {
+	LogicResetsVF  bool
+	ShiftVY        bool
+	LoadStoreIndex byte
+	JumpVX         bool
+	SpriteEdge     byte
+	StackDepth     uint16
+	SuperChip      bool
+	XOChip         bool
+}
+
+// stateRegisters holds the fixed size part of the machine state,
+// it is followed by the memory and the two planes
+type stateRegisters struct {
+	Quirks       stateQuirks
+	I            uint16
+	PC           uint16
+	SP           uint16
+	DelayTimer   byte
+	SoundTimer   byte
+	V            [16]byte
+	CallStack    [256]uint16
+	HiRes        bool
+	Planes       byte
+	AudioPattern [16]byte
+	Pitch        byte
+	RPL          [16]byte
+	Halted       bool
+	Rand         uint64
+	WaitingKey   bool
+	WaitKey      byte
+	TimerClock   int64
+	Cycles       uint64
+	MemorySize   uint32
+}
+
+// SaveState writes the whole machine state: registers, timers, stack,
+// memory, screen, quirks and random number generator.
+// Display and Input are not part of the state.
+func (m *Memory) SaveState(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := stateHeader{Magic: stateMagic, Version: StateVersion}
+	if err := binary.Write(bw, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	regs := stateRegisters{
+		Quirks: stateQuirks{
+			LogicResetsVF:  m.Quirks.LogicResetsVF,
+			ShiftVY:        m.Quirks.ShiftVY,
+			LoadStoreIndex: byte(m.Quirks.LoadStoreIndex),
+			JumpVX:         m.Quirks.JumpVX,
+			SpriteEdge:     byte(m.Quirks.SpriteEdge),
+			StackDepth:     uint16(m.Quirks.StackDepth),
+			SuperChip:      m.Quirks.SuperChip,
+			XOChip:         m.Quirks.XOChip,
+		},
+		I:            m.I,
+		PC:           m.PC,
+		SP:           m.SP,
+		DelayTimer:   m.DelayTimer,
+		SoundTimer:   m.SoundTimer,
+		V:            m.V,
+		CallStack:    m.CallStack,
+		HiRes:        m.HiRes,
+		Planes:       m.Planes,
+		AudioPattern: m.AudioPattern,
+		Pitch:        m.Pitch,
+		RPL:          m.RPL,
+		Halted:       m.Halted,
+		Rand:         m.Rand.State,
+		WaitingKey:   m.waitingKey,
+		WaitKey:      m.waitKey,
+		TimerClock:   int64(m.timerClock),
+		Cycles:       m.Cycles,
+		MemorySize:   uint32(m.MemorySize()),
+	}
+	if err := binary.Write(bw, binary.BigEndian, &regs); err != nil {
+		return err
+	}
+	if _, err := bw.Write(m.Memory[:m.MemorySize()]); err != nil {
+		return err
+	}
+	for _, plane := range [][][]bool{m.Screen, m.Screen2} {
+		if _, err := bw.Write(packPlane(plane)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadState restores a machine state written by SaveState.
+// The chip8 is left unchanged if an error is returned.
+func (m *Memory) LoadState(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var header stateHeader
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		return ErrNotAState
+	}
+	if header.Magic != stateMagic {
+		return ErrNotAState
+	}
+	if header.Version != StateVersion {
+		return &StateVersionError{Version: header.Version}
+	}
+	var regs stateRegisters
+	if err := binary.Read(br, binary.BigEndian, &regs); err != nil {
+		return err
+	}
+	if int(regs.MemorySize) > len(m.Memory) {
+		return ErrNotAState
+	}
+	restored := *m
+	restored.Memory = [len(m.Memory)]byte{}
+	if _, err := io.ReadFull(br, restored.Memory[:regs.MemorySize]); err != nil {
+		return err
+	}
+	restored.Quirks = Quirks{
+		LogicResetsVF:  regs.Quirks.LogicResetsVF,
+		ShiftVY:        regs.Quirks.ShiftVY,
+		LoadStoreIndex: IndexIncrement(regs.Quirks.LoadStoreIndex),
+		JumpVX:         regs.Quirks.JumpVX,
+		SpriteEdge:     SpriteEdge(regs.Quirks.SpriteEdge),
+		StackDepth:     int(regs.Quirks.StackDepth),
+		SuperChip:      regs.Quirks.SuperChip,
+		XOChip:         regs.Quirks.XOChip,
+	}
+	restored.setResolution(regs.HiRes)
+	for _, plane := range [][][]bool{restored.Screen, restored.Screen2} {
+		packed := make([]byte, packedPlaneSize(plane))
+		if _, err := io.ReadFull(br, packed); err != nil {
+			return err
+		}
+		unpackPlane(plane, packed)
+	}
+	restored.I = regs.I
+	restored.PC = regs.PC
+	restored.SP = regs.SP
+	restored.DelayTimer = regs.DelayTimer
+	restored.SoundTimer = regs.SoundTimer
+	restored.V = regs.V
+	restored.CallStack = regs.CallStack
+	restored.Planes = regs.Planes
+	restored.AudioPattern = regs.AudioPattern
+	restored.Pitch = regs.Pitch
+	restored.RPL = regs.RPL
+	restored.Halted = regs.Halted
+	restored.Rand.State = regs.Rand
+	restored.waitingKey = regs.WaitingKey
+	restored.waitKey = regs.WaitKey
+	restored.timerClock = time.Duration(regs.TimerClock)
+	restored.Cycles = regs.Cycles
+	if err := restored.checkRegisters(); err != nil {
+		return err
+	}
+	*m = restored
+	m.refreshDisplay()
+	return nil
+}
+
+// checkRegisters returns an error when SP is past the stack
+// or when PC can not fetch an instruction
+func (m *Memory) checkRegisters() error {
+	if int(m.SP) > len(m.CallStack) || int(m.SP) > m.StackDepth() {
+		return &StateRegisterError{Register: "SP", Value: int(m.SP)}
+	}
+	if int(m.PC)+1 >= m.MemorySize() {
+		return &StateRegisterError{Register: "PC", Value: int(m.PC)}
+	}
+	return nil
+}
+
+// SaveStateFile writes the machine state in the given file
+func (m *Memory) SaveStateFile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		myLogger.Error.Println("Couldn't create the save state <" + filePath + ">")
+		return err
+	}
+	defer file.Close()
+	if err := m.SaveState(file); err != nil {
+		myLogger.Error.Println("Couldn't write the save state <" + filePath + ">")
+		return err
+	}
+	myLogger.Info.Println("State saved in <" + filePath + ">")
+	return nil
+}
+
+// LoadStateFile restores the machine state from the given file
+func (m *Memory) LoadStateFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		myLogger.Error.Println("file:<" + filePath + "> not found")
+		return err
+	}
+	defer file.Close()
+	if err := m.LoadState(file); err != nil {
+		myLogger.Error.Println("Couldn't restore the save state <" + filePath + ">: " + err.Error())
+		return err
+	}
+	myLogger.Info.Println("State restored from <" + filePath + ">")
+	return nil
+}
+
+// packedPlaneSize returns the size of a plane packed 8 pixels per byte
+func packedPlaneSize(plane [][]bool) int {
+	return (len(plane)*len(plane[0]) + 7) / 8
+}
+
+// packPlane packs a plane 8 pixels per byte, column by column
+func packPlane(plane [][]bool) []byte {
+	packed := make([]byte, packedPlaneSize(plane))
+	i := 0
+	for _, column := range plane {
+		for _, b := range column {
+			if b {
+				packed[i/8] |= 0x80 >> uint(i%8)
+			}
+			i++
+		}
+	}
+	return packed
+}
+
+// unpackPlane fills a plane with pixels packed by packPlane
+func unpackPlane(plane [][]bool, packed []byte) {
+	i := 0
+	for _, column := range plane {
+		for y := range column {
+			column[y] = packed[i/8]&(0x80>>uint(i%8)) != 0
+			i++
+		}
+	}
+}
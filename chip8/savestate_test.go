@@ -0,0 +1,201 @@
+package chip8
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SaveStateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SaveStateTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createBusyMem creates a chip8 with a state different from a fresh one
+func createBusyMem(q Quirks) *Memory {
+	m := NewSeededMemory(q, 99)
+	m.LoadRom("../rom/PONG")
+	for i := 0; i < 500; i++ {
+		m.Iterate()
+		if i%10 == 0 {
+			m.TickTimers()
+		}
+	}
+	m.V[3] = 0x42
+	m.DelayTimer = 12
+	m.SoundTimer = 3
+	m.RPL[2] = 9
+	return m
+}
+
+func (suite *SaveStateTestSuite) TestRoundTrip() {
+	for name, q := range quirksPresets {
+		// Adapt
+		m := createBusyMem(q)
+		var buf bytes.Buffer
+
+		// Act
+		err := m.SaveState(&buf)
+		restored := NewMemory(QuirksVIP)
+		errLoad := restored.LoadState(&buf)
+
+		// Assert
+		assert.Nil(suite.T(), err, "Saved "+name)
+		assert.Nil(suite.T(), errLoad, "Restored "+name)
+		assert.Equal(suite.T(), m, restored, "Same state "+name)
+	}
+}
+
+func (suite *SaveStateTestSuite) TestRoundTrip_HiResPlanes() {
+	// Adapt
+	m := NewSeededMemory(QuirksXOCHIP, 1)
+	m.Decode(0x00FF)
+	m.Screen[127][63] = true
+	m.Screen2[5][7] = true
+	m.Memory[0xFFFF] = 0xAB
+	var buf bytes.Buffer
+
+	// Act
+	m.SaveState(&buf)
+	restored := NewMemory(QuirksVIP)
+	restored.LoadState(&buf)
+
+	// Assert
+	assert.Equal(suite.T(), m, restored, "Same state")
+}
+
+func (suite *SaveStateTestSuite) TestReplay() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+	var buf bytes.Buffer
+	m.SaveState(&buf)
+	restored := NewMemory(QuirksVIP)
+	restored.LoadState(&buf)
+
+	// Act
+	for i := 0; i < 1000; i++ {
+		m.Iterate()
+		restored.Iterate()
+	}
+
+	// Assert
+	assert.Equal(suite.T(), m, restored, "Same execution after restore")
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_NotAState() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+	pc := m.PC
+
+	// Act
+	err := m.LoadState(bytes.NewReader([]byte("not a state at all")))
+
+	// Assert
+	assert.Equal(suite.T(), ErrNotAState, err, "Bad magic")
+	assert.Equal(suite.T(), pc, m.PC, "State unchanged")
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_BadVersion() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+	var buf bytes.Buffer
+	m.SaveState(&buf)
+	data := buf.Bytes()
+	data[5] = 0xFF
+
+	// Act
+	err := NewMemory(QuirksVIP).LoadState(bytes.NewReader(data))
+
+	// Assert
+	assert.IsType(suite.T(), &StateVersionError{}, err, "Unknown version")
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_Truncated() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+	var buf bytes.Buffer
+	m.SaveState(&buf)
+	restored := NewMemory(QuirksVIP)
+
+	// Act
+	err := restored.LoadState(bytes.NewReader(buf.Bytes()[:buf.Len()-10]))
+
+	// Assert
+	assert.NotNil(suite.T(), err, "Truncated state")
+	assert.Equal(suite.T(), uint16(0x200), restored.PC, "State unchanged")
+}
+
+// loadCorrupted saves m after corrupt changed it and restores it in a fresh chip8
+func loadCorrupted(m *Memory, corrupt func(m *Memory)) (*Memory, error) {
+	corrupt(m)
+	var buf bytes.Buffer
+	m.SaveState(&buf)
+	restored := NewMemory(m.Quirks)
+	return restored, restored.LoadState(&buf)
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_SPPastCallStack() {
+	// Adapt
+	m := createBusyMem(Quirks{})
+
+	// Act
+	restored, err := loadCorrupted(m, func(m *Memory) { m.SP = uint16(len(m.CallStack) + 1) })
+
+	// Assert
+	assert.Equal(suite.T(), &StateRegisterError{Register: "SP", Value: 257}, err, "SP past the call stack")
+	assert.Equal(suite.T(), uint16(0), restored.SP, "State unchanged")
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_SPPastStackDepth() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+
+	// Act
+	restored, err := loadCorrupted(m, func(m *Memory) { m.SP = 13 })
+
+	// Assert
+	assert.Equal(suite.T(), &StateRegisterError{Register: "SP", Value: 13}, err, "SP past the 12 calls of the VIP")
+	assert.Equal(suite.T(), uint16(0), restored.SP, "State unchanged")
+}
+
+func (suite *SaveStateTestSuite) TestLoadState_PCPastMemory() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+
+	// Act
+	restored, err := loadCorrupted(m, func(m *Memory) { m.PC = 0xFFF })
+
+	// Assert
+	assert.Equal(suite.T(), &StateRegisterError{Register: "PC", Value: 0xFFF}, err, "No room for an opcode at PC")
+	assert.Equal(suite.T(), uint16(0x200), restored.PC, "State unchanged")
+}
+
+func (suite *SaveStateTestSuite) TestStateFile() {
+	// Adapt
+	m := createBusyMem(QuirksVIP)
+	dir, _ := os.MkdirTemp("", "chip8")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "PONG.state1")
+
+	// Act
+	err := m.SaveStateFile(path)
+	restored := NewMemory(QuirksVIP)
+	errLoad := restored.LoadStateFile(path)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Saved")
+	assert.Nil(suite.T(), errLoad, "Restored")
+	assert.Equal(suite.T(), m, restored, "Same state")
+}
+
+func TestSaveStateTestSuite(t *testing.T) {
+	suite.Run(t, new(SaveStateTestSuite))
+}
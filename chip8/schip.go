@@ -0,0 +1,165 @@
+package chip8
+
+import (
+	"errors"
+)
+
+// ErrHalted is returned once the program exited with 00FD
+var ErrHalted = errors.New("chip8: program exited")
+
+// fSuperChipFunctionMap holds the FX?? opcodes added by the SUPER-CHIP
+var fSuperChipFunctionMap = map[uint16]func(*Memory, uint16) error{0x30: FGoToBigSprite, 0x75: FSaveFlags, 0x85: FLoadFlags}
+
+// setResolution switches between the 64x32 and 128x64 modes
+// and gives cleared planes of the new size
+func (m *Memory) setResolution(hiRes bool) {
+	width, height := LowResWidth, LowResHeight
+	if hiRes {
+		width, height = HighResWidth, HighResHeight
+	}
+	m.HiRes = hiRes
+	m.Screen = newPlane(width, height)
+	m.Screen2 = newPlane(width, height)
+}
+
+// newPlane allocates a cleared bit plane
+func newPlane(width, height int) [][]bool {
+	plane := make([][]bool, width)
+	for i := range plane {
+		plane[i] = make([]bool, height)
+	}
+	return plane
+}
+
+// superChipZeroDispatcher runs the 00?? opcodes added by the SUPER-CHIP,
+// it returns false if the opcode is not one of them
+func superChipZeroDispatcher(m *Memory, opcode uint16) (bool, error) {
+	switch {
+	case opcode&0xFFF0 == 0x00C0:
+		return true, ZeroScrollDown(m, opcode)
+	case opcode&0xFFF0 == 0x00D0 && m.Quirks.XOChip:
+		return true, ZeroScrollUp(m, opcode)
+	case opcode == 0x00FB:
+		return true, ZeroScrollRight(m, opcode)
+	case opcode == 0x00FC:
+		return true, ZeroScrollLeft(m, opcode)
+	case opcode == 0x00FD:
+		return true, ZeroExit(m, opcode)
+	case opcode == 0x00FE:
+		return true, ZeroLowRes(m, opcode)
+	case opcode == 0x00FF:
+		return true, ZeroHighRes(m, opcode)
+	}
+	return false, nil
+}
+
+// ZeroScrollDown is the 00CN opcode
+// which scrolls the screen down by N pixels
+func ZeroScrollDown(m *Memory, opcode uint16) error {
+	for _, plane := range m.selectedPlanes() {
+		scrollVertically(plane, int(opcode&0x000F))
+	}
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// ZeroScrollRight is the 00FB opcode
+// which scrolls the screen right by 4 pixels
+func ZeroScrollRight(m *Memory, opcode uint16) error {
+	for _, plane := range m.selectedPlanes() {
+		scrollHorizontally(plane, 4)
+	}
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// ZeroScrollLeft is the 00FC opcode
+// which scrolls the screen left by 4 pixels
+func ZeroScrollLeft(m *Memory, opcode uint16) error {
+	for _, plane := range m.selectedPlanes() {
+		scrollHorizontally(plane, -4)
+	}
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// scrollVertically moves the plane n pixels down, or up if n is negative
+func scrollVertically(plane [][]bool, n int) {
+	for _, column := range plane {
+		height := len(column)
+		for i := 0; i < height; i++ {
+			y := i
+			if n > 0 {
+				y = height - 1 - i
+			}
+			src := y - n
+			column[y] = src >= 0 && src < height && column[src]
+		}
+	}
+}
+
+// scrollHorizontally moves the plane n pixels right, or left if n is negative
+func scrollHorizontally(plane [][]bool, n int) {
+	width := len(plane)
+	for i := 0; i < width; i++ {
+		x := i
+		if n > 0 {
+			x = width - 1 - i
+		}
+		src := x - n
+		for y := range plane[x] {
+			plane[x][y] = src >= 0 && src < width && plane[src][y]
+		}
+	}
+}
+
+// ZeroExit is the 00FD opcode
+// which exits the interpreter, PC stays on it
+func ZeroExit(m *Memory, opcode uint16) error {
+	m.Halted = true
+	return ErrHalted
+}
+
+// ZeroLowRes is the 00FE opcode
+// which switches to the 64x32 mode
+func ZeroLowRes(m *Memory, opcode uint16) error {
+	m.setResolution(false)
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// ZeroHighRes is the 00FF opcode
+// which switches to the 128x64 mode
+func ZeroHighRes(m *Memory, opcode uint16) error {
+	m.setResolution(true)
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// FGoToBigSprite is the FX30 opcode
+// which sets I to the location of the 8x10 sprite for the digit in VX
+func FGoToBigSprite(m *Memory, opcode uint16) error {
+	m.I = bigFontAddress + uint16(10)*uint16(m.V[(0x0F00&opcode)>>8]&0x0F)
+	return nil
+}
+
+// FSaveFlags is the FX75 opcode
+// which stores V0 to VX in the RPL user flags
+func FSaveFlags(m *Memory, opcode uint16) error {
+	vx := (opcode & 0x0F00) >> 8
+	copy(m.RPL[:vx+1], m.V[:vx+1])
+	return nil
+}
+
+// FLoadFlags is the FX85 opcode
+// which fills V0 to VX with the RPL user flags
+func FLoadFlags(m *Memory, opcode uint16) error {
+	vx := (opcode & 0x0F00) >> 8
+	copy(m.V[:vx+1], m.RPL[:vx+1])
+	return nil
+}
@@ -0,0 +1,200 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuperChipTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SuperChipTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *SuperChipTestSuite) TestInit_BigFont() {
+	// Act
+	m := NewMemory(QuirksSCHIP)
+
+	// Assert
+	for i := range schipBigFontset {
+		assert.Equal(suite.T(), schipBigFontset[i], m.Memory[bigFontAddress+i], "Big font loaded")
+	}
+	assert.False(suite.T(), m.HiRes, "Start in low resolution")
+}
+
+func (suite *SuperChipTestSuite) TestResolution() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Screen[0][0] = true
+
+	// Act
+	m.Decode(0x00FF)
+
+	// Assert
+	assert.True(suite.T(), m.HiRes, "High resolution")
+	assert.Equal(suite.T(), HighResWidth, len(m.Screen), "Screen width")
+	assert.Equal(suite.T(), HighResHeight, len(m.Screen[0]), "Screen height")
+	assert.False(suite.T(), m.Screen[0][0], "Screen cleared")
+
+	// Act
+	m.Decode(0x00FE)
+
+	// Assert
+	assert.False(suite.T(), m.HiRes, "Low resolution")
+	assert.Equal(suite.T(), LowResWidth, len(m.Screen), "Screen width")
+	assert.Equal(suite.T(), uint16(0x204), m.PC, "Move to the next instructions")
+}
+
+func (suite *SuperChipTestSuite) TestDisabledWithoutQuirk() {
+	// Adapt
+	m := NewMemory(QuirksVIP)
+
+	// Act
+	m.Decode(0x00FF)
+	err := m.Decode(0xF030)
+
+	// Assert
+	assert.False(suite.T(), m.HiRes, "00FF ignored")
+	assert.IsType(suite.T(), &InvalidOpcodeError{}, err, "FX30 unknown")
+}
+
+func (suite *SuperChipTestSuite) TestScrollDown() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Screen[5][0] = true
+	m.Screen[5][30] = true
+
+	// Act
+	m.Decode(0x00C3)
+
+	// Assert
+	assert.False(suite.T(), m.Screen[5][0], "Pixel moved")
+	assert.True(suite.T(), m.Screen[5][3], "Pixel moved down")
+	assert.False(suite.T(), m.Screen[5][30], "Pixel scrolled out")
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
+}
+
+func (suite *SuperChipTestSuite) TestScrollRightLeft() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Screen[0][7] = true
+	m.Screen[62][8] = true
+
+	// Act
+	m.Decode(0x00FB)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[4][7], "Pixel moved right")
+	assert.False(suite.T(), m.Screen[0][7], "Pixel moved")
+	assert.False(suite.T(), m.Screen[62][8], "Pixel scrolled out")
+
+	// Act
+	m.Decode(0x00FC)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[0][7], "Pixel moved left")
+	assert.False(suite.T(), m.Screen[4][7], "Pixel moved")
+}
+
+func (suite *SuperChipTestSuite) TestExit() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Memory[0x200] = 0x00
+	m.Memory[0x201] = 0xFD
+
+	// Act
+	err := m.Iterate()
+	err2 := m.Iterate()
+
+	// Assert
+	assert.Equal(suite.T(), ErrHalted, err, "Program exited")
+	assert.Equal(suite.T(), ErrHalted, err2, "Still halted")
+	assert.True(suite.T(), m.Halted, "Halted")
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "PC stays on exit")
+}
+
+func (suite *SuperChipTestSuite) TestDXY0_BigSprite() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Decode(0x00FF)
+	m.I = 0x300
+	for i := 0; i < 32; i++ {
+		m.Memory[0x300+i] = 0xFF
+	}
+	m.V[0] = 10
+	m.V[1] = 20
+
+	// Act
+	m.Decode(0xD010)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[10][20], "Top left")
+	assert.True(suite.T(), m.Screen[25][35], "Bottom right")
+	assert.False(suite.T(), m.Screen[26][35], "16 pixels wide")
+	assert.False(suite.T(), m.Screen[25][36], "16 pixels high")
+	assert.Equal(suite.T(), byte(0), m.V[0xF], "No collision")
+
+	// Act
+	m.Decode(0xD010)
+
+	// Assert
+	assert.False(suite.T(), m.Screen[10][20], "Erased")
+	assert.Equal(suite.T(), byte(16), m.V[0xF], "Colliding rows counted")
+}
+
+func (suite *SuperChipTestSuite) TestDXYN_HiResClippedRows() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.Decode(0x00FF)
+	m.I = 0
+	m.V[0] = 0
+	m.V[1] = 62
+
+	// Act
+	m.Decode(0xD015)
+
+	// Assert
+	assert.Equal(suite.T(), byte(3), m.V[0xF], "Clipped rows counted")
+}
+
+func (suite *SuperChipTestSuite) TestFX30() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	m.V[4] = 7
+
+	// Act
+	m.Decode(0xF430)
+
+	// Assert
+	assert.Equal(suite.T(), uint16(bigFontAddress+70), m.I, "I on the big 7")
+	assert.Equal(suite.T(), uint16(0x202), m.PC, "Move to the next instruction")
+}
+
+func (suite *SuperChipTestSuite) TestFX75_FX85() {
+	// Adapt
+	m := NewMemory(QuirksSCHIP)
+	for i := range m.V {
+		m.V[i] = byte(i + 1)
+	}
+
+	// Act
+	m.Decode(0xF375)
+	for i := range m.V {
+		m.V[i] = 0
+	}
+	m.Decode(0xF285)
+
+	// Assert
+	assert.Equal(suite.T(), [16]byte{1, 2, 3, 4}, m.RPL, "V0 to V3 saved")
+	assert.Equal(suite.T(), byte(3), m.V[2], "V2 loaded")
+	assert.Equal(suite.T(), byte(0), m.V[3], "V3 not loaded")
+}
+
+func TestSuperChipTestSuite(t *testing.T) {
+	suite.Run(t, new(SuperChipTestSuite))
+}
@@ -0,0 +1,31 @@
+package chip8
+
+// SpriteEdge is what DXYN does with the sprite pixels
+// going past the right or bottom edge of the screen
+type SpriteEdge int
+
+const (
+	// ClipSprites drops the pixels past the edge, like the COSMAC VIP
+	ClipSprites SpriteEdge = iota
+	// WrapSprites draws the pixels past the edge on the other side of the screen
+	WrapSprites
+)
+
+// String returns the name used on the command line for the SpriteEdge
+func (e SpriteEdge) String() string {
+	if e == WrapSprites {
+		return "wrap"
+	}
+	return "clip"
+}
+
+// ParseSpriteEdge returns the SpriteEdge named by s, "clip" or "wrap"
+func ParseSpriteEdge(s string) (SpriteEdge, bool) {
+	switch s {
+	case "clip":
+		return ClipSprites, true
+	case "wrap":
+		return WrapSprites, true
+	}
+	return ClipSprites, false
+}
@@ -0,0 +1,109 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SpriteTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SpriteTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createSpriteMem creates a chip8 with I on a 2 rows sprite of 8 lit pixels
+func createSpriteMem(edge SpriteEdge) *Memory {
+	m := createBasicMem()
+	m.Quirks.SpriteEdge = edge
+	m.I = 0x300
+	m.Memory[0x300] = 0xFF
+	m.Memory[0x301] = 0xFF
+	return m
+}
+
+func (suite *SpriteTestSuite) TestStartCoordinateWraps() {
+	// Adapt
+	m := createSpriteMem(ClipSprites)
+	m.V[0] = 64 + 3
+	m.V[1] = 32 + 5
+
+	// Act
+	m.Decode(0xD011)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[3][5], "Start X and Y wrapped")
+	assert.True(suite.T(), m.Screen[10][5], "Whole row drawn")
+	assert.False(suite.T(), m.Screen[11][5], "Only 8 pixels")
+}
+
+func (suite *SpriteTestSuite) TestClip() {
+	// Adapt
+	m := createSpriteMem(ClipSprites)
+	m.V[0] = 60
+	m.V[1] = 31
+
+	// Act
+	err := m.Decode(0xD012)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.True(suite.T(), m.Screen[63][31], "Last pixel drawn")
+	assert.False(suite.T(), m.Screen[0][31], "X clipped")
+	assert.False(suite.T(), m.Screen[60][0], "Y clipped")
+	assert.False(suite.T(), m.Screen[0][0], "Corner clipped")
+}
+
+func (suite *SpriteTestSuite) TestWrap() {
+	// Adapt
+	m := createSpriteMem(WrapSprites)
+	m.V[0] = 60
+	m.V[1] = 31
+
+	// Act
+	err := m.Decode(0xD012)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.True(suite.T(), m.Screen[63][31], "Last pixel drawn")
+	assert.True(suite.T(), m.Screen[3][31], "X wrapped")
+	assert.False(suite.T(), m.Screen[4][31], "Only 8 pixels")
+	assert.True(suite.T(), m.Screen[60][0], "Y wrapped")
+	assert.True(suite.T(), m.Screen[0][0], "Corner wrapped")
+}
+
+func (suite *SpriteTestSuite) TestWrap_Collision() {
+	// Adapt
+	m := createSpriteMem(WrapSprites)
+	m.V[0] = 60
+	m.Screen[2][0] = true
+
+	// Act
+	m.Decode(0xD011)
+
+	// Assert
+	assert.Equal(suite.T(), byte(1), m.V[0xF], "Collision on a wrapped pixel")
+	assert.False(suite.T(), m.Screen[2][0], "Pixel erased")
+}
+
+func (suite *SpriteTestSuite) TestParseSpriteEdge() {
+	// Act
+	clip, okClip := ParseSpriteEdge("clip")
+	wrap, okWrap := ParseSpriteEdge("wrap")
+	_, okBad := ParseSpriteEdge("bad")
+
+	// Assert
+	assert.True(suite.T(), okClip && okWrap, "Known modes")
+	assert.False(suite.T(), okBad, "Unknown mode")
+	assert.Equal(suite.T(), ClipSprites, clip, "clip")
+	assert.Equal(suite.T(), WrapSprites, wrap, "wrap")
+	assert.Equal(suite.T(), "wrap", wrap.String(), "Name")
+}
+
+func TestSpriteTestSuite(t *testing.T) {
+	suite.Run(t, new(SpriteTestSuite))
+}
@@ -0,0 +1,77 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StackTestSuite struct {
+	suite.Suite
+}
+
+func (suite *StackTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *StackTestSuite) TestStackDepth() {
+	// Adapt
+	vip := NewMemory(QuirksVIP)
+	schip := NewMemory(QuirksSCHIP)
+	legacy := createBasicMem()
+
+	// Assert
+	assert.Equal(suite.T(), 12, vip.StackDepth(), "VIP depth")
+	assert.Equal(suite.T(), 16, schip.StackDepth(), "SUPER-CHIP depth")
+	assert.Equal(suite.T(), 256, legacy.StackDepth(), "Whole call stack")
+}
+
+func (suite *StackTestSuite) TestOverflow() {
+	// Adapt
+	m := NewMemory(QuirksVIP)
+	// 2200 calls itself forever
+	m.Memory[0x200] = 0x22
+	m.Memory[0x201] = 0x00
+
+	// Act
+	var err error
+	for i := 0; i < 13 && err == nil; i++ {
+		err = m.Iterate()
+	}
+
+	// Assert
+	overflow, ok := err.(*StackOverflowError)
+	if assert.True(suite.T(), ok, "Stack overflow") {
+		assert.Equal(suite.T(), uint16(0x200), overflow.PC, "PC of the call")
+		assert.Equal(suite.T(), 12, len(overflow.Stack), "Stack content")
+	}
+	assert.Equal(suite.T(), uint16(12), m.SP, "SP not moved")
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the faulty call")
+}
+
+func (suite *StackTestSuite) TestUnderflow() {
+	// Adapt
+	m := NewMemory(QuirksVIP)
+
+	// Act
+	err := m.Decode(0x00EE)
+
+	// Assert
+	assert.Equal(suite.T(), &StackUnderflowError{PC: 0x200, Stack: []uint16{}}, err, "Stack underflow")
+	assert.Equal(suite.T(), uint16(0), m.SP, "SP not moved")
+	assert.Equal(suite.T(), uint16(0x200), m.PC, "Stay on the faulty return")
+}
+
+func (suite *StackTestSuite) TestErrorMessage() {
+	// Adapt
+	err := &StackOverflowError{PC: 0x234, Stack: []uint16{0x200, 0x232}}
+
+	// Assert
+	assert.Equal(suite.T(), "stack overflow at 0x0234: stack [0x0200 0x0232]", err.Error(), "Message")
+}
+
+func TestStackTestSuite(t *testing.T) {
+	suite.Run(t, new(StackTestSuite))
+}
@@ -0,0 +1,145 @@
+package chip8_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/headless"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// suiteDir holds the roms of the community test suite and the result
+// screens they should end on, see its README
+const suiteDir = "../test/chip8-test-suite"
+
+// suiteFrames is how long a rom of the suite runs before its screen is read
+const suiteFrames = 600
+
+// selectAddress is where the suite reads the test or the platform to run
+// instead of asking for it in a menu
+const selectAddress = 0x1FF
+
+// suiteRun is a run of a rom of the suite, its result screen is name.txt
+type suiteRun struct {
+	name   string
+	rom    string
+	quirks chip8.Quirks
+	// selection is written at selectAddress when it is not 0
+	selection byte
+	script    headless.Script
+}
+
+var suiteRuns = []suiteRun{
+	{name: "corax+", rom: "3-corax+.ch8", quirks: chip8.QuirksVIP},
+	{name: "flags", rom: "4-flags.ch8", quirks: chip8.QuirksVIP},
+	{name: "quirks-vip", rom: "5-quirks.ch8", quirks: chip8.QuirksVIP, selection: 1},
+	{name: "quirks-schip", rom: "5-quirks.ch8", quirks: chip8.QuirksSCHIP, selection: 4},
+	{name: "quirks-xochip", rom: "5-quirks.ch8", quirks: chip8.QuirksXOCHIP, selection: 3},
+	{name: "keypad-fx0a", rom: "6-keypad.ch8", quirks: chip8.QuirksVIP, selection: 3, script: headless.Script{
+		{Frame: 60, Key: 5, Pressed: true},
+		{Frame: 70, Key: 5},
+	}},
+}
+
+type SuiteTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SuiteTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// runSuiteRom runs a rom of the suite and returns its screen as written by
+// headless.WriteASCII, split in lines
+func runSuiteRom(run suiteRun) ([]string, error) {
+	m := chip8.NewSeededMemory(run.quirks, 1)
+	if err := m.LoadRom(filepath.Join(suiteDir, run.rom)); err != nil {
+		return nil, err
+	}
+	if run.selection != 0 {
+		m.Memory[selectAddress] = run.selection
+	}
+	if err := headless.Run(m, headless.Options{Frames: suiteFrames, Script: run.script}); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	headless.WriteASCII(&buf, m)
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
+
+// textLines splits a result screen in its lines of text: the runs of rows
+// with a lit pixel, it returns the first and last row of each
+func textLines(screen []string) [][2]int {
+	var lines [][2]int
+	start := -1
+	for y, row := range screen {
+		blank := strings.Trim(row, ".") == ""
+		if !blank && start < 0 {
+			start = y
+		}
+		if blank && start >= 0 {
+			lines = append(lines, [2]int{start, y - 1})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		lines = append(lines, [2]int{start, len(screen) - 1})
+	}
+	return lines
+}
+
+// TestCommunitySuite runs the roms of the suite and reports every line of
+// their result screens as a subtest, against the screens the suite documents
+func (suite *SuiteTestSuite) TestCommunitySuite() {
+	for _, run := range suiteRuns {
+		run := run
+		suite.Run(run.name, func() {
+			// Adapt
+			if _, err := os.Stat(filepath.Join(suiteDir, run.rom)); os.IsNotExist(err) {
+				suite.T().Skipf("%s is not vendored in %s, see its README", run.rom, suiteDir)
+			}
+			want, err := ioutil.ReadFile(filepath.Join(suiteDir, run.name+".txt"))
+			if os.IsNotExist(err) {
+				suite.T().Skipf("no result screen %s.txt in %s, see its README", run.name, suiteDir)
+			}
+			expected := strings.Split(strings.TrimSuffix(string(want), "\n"), "\n")
+
+			// Act
+			screen, err := runSuiteRom(run)
+
+			// Assert
+			if !assert.Nil(suite.T(), err, "Run of %s", run.rom) {
+				return
+			}
+			if !assert.Equal(suite.T(), len(expected), len(screen), "Screen height") {
+				return
+			}
+			blank := 0
+			for _, line := range textLines(expected) {
+				line := line
+				suite.Run(fmt.Sprintf("line %d-%d", line[0], line[1]), func() {
+					assert.Equal(suite.T(), strings.Join(expected[line[0]:line[1]+1], "\n"),
+						strings.Join(screen[line[0]:line[1]+1], "\n"))
+				})
+				// the rows between the lines are blank in both screens
+				assert.Equal(suite.T(), strings.Join(expected[blank:line[0]], "\n"),
+					strings.Join(screen[blank:line[0]], "\n"), "Blank rows before line %d", line[0])
+				blank = line[1] + 1
+			}
+			assert.Equal(suite.T(), strings.Join(expected[blank:], "\n"),
+				strings.Join(screen[blank:], "\n"), "Blank rows at the bottom")
+		})
+	}
+}
+
+func TestSuiteTestSuite(t *testing.T) {
+	suite.Run(t, new(SuiteTestSuite))
+}
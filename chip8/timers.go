@@ -0,0 +1,41 @@
+package chip8
+
+import (
+	"time"
+)
+
+// TimerFrequency is the rate, in Hz, at which the delay and sound timers
+// count down on the original hardware
+const TimerFrequency = 60
+
+// UpdateTimers advances the delay and sound timers by the given amount
+// of real time. The timers count down at exactly TimerFrequency no matter
+// how many times Iterate has been called in between, the leftover time
+// is kept for the next call.
+func (m *Memory) UpdateTimers(elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	m.timerClock += elapsed * TimerFrequency
+	for m.timerClock >= time.Second {
+		m.timerClock -= time.Second
+		m.TickTimers()
+	}
+}
+
+// TickTimers does one 60 Hz tick
+// which decrements both timers if they are not already at zero
+func (m *Memory) TickTimers() {
+	if m.DelayTimer > 0 {
+		m.DelayTimer--
+	}
+	if m.SoundTimer > 0 {
+		m.SoundTimer--
+	}
+}
+
+// SoundActive tells if the buzzer should be sounding,
+// which is the case as long as the sound timer is not zero
+func (m *Memory) SoundActive() bool {
+	return m.SoundTimer > 0
+}
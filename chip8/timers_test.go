@@ -0,0 +1,97 @@
+package chip8
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TimersTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TimersTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *TimersTestSuite) TestTickTimers() {
+	// Adapt
+	m := createBasicMem()
+	m.DelayTimer = 2
+	m.SoundTimer = 1
+
+	// Act
+	m.TickTimers()
+
+	// Assert
+	assert.Equal(suite.T(), byte(1), m.DelayTimer, "Delay timer decremented")
+	assert.Equal(suite.T(), byte(0), m.SoundTimer, "Sound timer decremented")
+	assert.False(suite.T(), m.SoundActive(), "Sound stopped")
+}
+
+func (suite *TimersTestSuite) TestTickTimers_StopAtZero() {
+	// Adapt
+	m := createBasicMem()
+
+	// Act
+	m.TickTimers()
+
+	// Assert
+	assert.Equal(suite.T(), byte(0), m.DelayTimer, "Delay timer stays at zero")
+	assert.Equal(suite.T(), byte(0), m.SoundTimer, "Sound timer stays at zero")
+}
+
+func (suite *TimersTestSuite) TestUpdateTimers_OneSecond() {
+	// Adapt
+	m := createBasicMem()
+	m.DelayTimer = 0xFF
+	m.SoundTimer = 0xFF
+
+	// Act
+	m.UpdateTimers(time.Second)
+
+	// Assert
+	assert.Equal(suite.T(), byte(0xFF-60), m.DelayTimer, "60 ticks in a second")
+	assert.Equal(suite.T(), byte(0xFF-60), m.SoundTimer, "60 ticks in a second")
+	assert.True(suite.T(), m.SoundActive(), "Sound still active")
+}
+
+func (suite *TimersTestSuite) TestUpdateTimers_SmallSteps() {
+	// Adapt
+	m := createBasicMem()
+	m.DelayTimer = 0xFF
+
+	// Act
+	for i := 0; i < 1000; i++ {
+		m.UpdateTimers(time.Millisecond)
+	}
+
+	// Assert
+	assert.Equal(suite.T(), byte(0xFF-60), m.DelayTimer, "Leftover time is kept between calls")
+}
+
+func (suite *TimersTestSuite) TestUpdateTimers_IndependentOfIterate() {
+	// Adapt
+	m := createBasicMem()
+	m.DelayTimer = 10
+	for i := 0; i < 0x100; i += 2 {
+		// 1200 jumps to itself
+		m.Memory[0x200+i] = 0x12
+		m.Memory[0x201+i] = 0x00
+	}
+
+	// Act
+	for i := 0; i < 500; i++ {
+		m.Iterate()
+	}
+
+	// Assert
+	assert.Equal(suite.T(), byte(10), m.DelayTimer, "Only time moves the timers")
+}
+
+func TestTimersTestSuite(t *testing.T) {
+	suite.Run(t, new(TimersTestSuite))
+}
@@ -0,0 +1,118 @@
+package chip8
+
+// The COSMAC VIP runs its 1802 at 1.76 MHz, a machine cycle takes
+// 8 clock pulses, which makes VIPFrameCycles machine cycles in a 60 Hz frame.
+// The display interrupt and its DMA take VIPDisplayCycles of them,
+// the interpreter runs during the VIPCyclesPerFrame left.
+const (
+	VIPFrameCycles    = 3668
+	VIPDisplayCycles  = 1832
+	VIPCyclesPerFrame = VIPFrameCycles - VIPDisplayCycles
+)
+
+// The costs below are in machine cycles and are approximate: they follow the
+// shape of the VIP interpreter, a fetch before every instruction and loops
+// over the rows of a sprite, the digits of a number or the registers, but
+// have not been checked against its listing or against the timing table of
+// Jackson Sommerich, "Chip-8 Instruction Scheduling and Frequency" (2019).
+// vipFetchCycles is the fetch and decode loop run before every instruction,
+// vipSkipCycles is added when a skip is taken and vipPageCycles when
+// BNNN or FX1E cross a 256 bytes page.
+const (
+	vipFetchCycles = 40
+	vipSkipCycles  = 4
+	vipPageCycles  = 2
+)
+
+// VIPCycles returns about the machine cycles the COSMAC VIP interpreter takes
+// to fetch and execute the instruction at PC, in the current state of the
+// chip8. The machine code routines of 0NNN and the opcodes the VIP does not
+// have only cost the fetch.
+func (m *Memory) VIPCycles() int {
+	opcode := m.Fetch()
+	x, y := xyExtractor(opcode)
+	n := int(opcode & 0x000F)
+	nn := byte(opcode)
+	cycles, skip := 0, false
+	switch opcode >> 12 {
+	case 0x0:
+		switch opcode {
+		case 0x00E0:
+			// the 256 bytes of the display are cleared one by one
+			cycles = 24 + 256*12
+		case 0x00EE:
+			cycles = 10
+		}
+	case 0x1:
+		cycles = 12
+	case 0x2:
+		cycles = 26
+	case 0x3:
+		cycles, skip = 10, m.V[x] == nn
+	case 0x4:
+		cycles, skip = 10, m.V[x] != nn
+	case 0x5:
+		cycles, skip = 14, m.V[x] == m.V[y]
+	case 0x6:
+		cycles = 6
+	case 0x7:
+		cycles = 10
+	case 0x8:
+		if n == 0 {
+			cycles = 12
+		} else {
+			cycles = 44
+		}
+	case 0x9:
+		cycles, skip = 14, m.V[x] != m.V[y]
+	case 0xA:
+		cycles = 12
+	case 0xB:
+		cycles = 22
+		if int(opcode&0xFF)+int(m.V[0]) > 0xFF {
+			cycles += vipPageCycles
+		}
+	case 0xC:
+		cycles = 36
+	case 0xD:
+		// every row of the sprite is shifted bit by bit to the X position in its byte
+		cycles = 26 + n*(46+8*int(m.V[x]&7))
+	case 0xE:
+		pressed := m.keyPressed(m.V[x])
+		cycles, skip = 14, nn == 0x9E && pressed || nn == 0xA1 && !pressed
+	case 0xF:
+		cycles = m.vipFCycles(x, nn)
+	}
+	if skip {
+		cycles += vipSkipCycles
+	}
+	return vipFetchCycles + cycles
+}
+
+// vipFCycles returns the execution cycles of the FXNN opcodes
+func (m *Memory) vipFCycles(x uint16, nn byte) int {
+	switch nn {
+	case 0x07, 0x0A, 0x15, 0x18:
+		return 10
+	case 0x1E:
+		if int(m.I&0xFF)+int(m.V[x]) > 0xFF {
+			return 16 + vipPageCycles
+		}
+		return 16
+	case 0x29:
+		return 16
+	case 0x33:
+		v := int(m.V[x])
+		// the digits are found by repeated subtractions
+		return 80 + 16*(v/100+v/10%10+v%10)
+	case 0x55, 0x65:
+		return 14 + 14*(int(x)+1)
+	}
+	return 0
+}
+
+// VIPWaitsDisplay tells if the instruction at PC waits for the vertical blank
+// on the VIP, which is what its draws do
+func (m *Memory) VIPWaitsDisplay() bool {
+	return m.Fetch()>>12 == 0xD
+}
@@ -0,0 +1,137 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TimingTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TimingTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createTimingMem creates a chip8 with the opcode at PC
+func createTimingMem(opcode uint16) *Memory {
+	m := createBasicMem()
+	m.Memory[m.PC] = byte(opcode >> 8)
+	m.Memory[m.PC+1] = byte(opcode)
+	return m
+}
+
+// vipTable holds the approximate fetch and execution cycles of timing.go
+// for the instructions of a fresh chip8, any change to a cost shows here
+var vipTable = map[uint16]int{
+	0x0123: 40,
+	0x00E0: 40 + 24 + 256*12,
+	0x00EE: 40 + 10,
+	0x1234: 40 + 12,
+	0x2300: 40 + 26,
+	0x6012: 40 + 6,
+	0x7001: 40 + 10,
+	0x8120: 40 + 12,
+	0x8124: 40 + 44,
+	0xA123: 40 + 12,
+	0xB000: 40 + 22,
+	0xC0FF: 40 + 36,
+	0xF007: 40 + 10,
+	0xF01E: 40 + 16,
+	0xF029: 40 + 16,
+	0xF255: 40 + 14 + 14*3,
+	0xF265: 40 + 14 + 14*3,
+	0xF2FF: 40,
+}
+
+func (suite *TimingTestSuite) TestVIPCycles() {
+	for opcode, want := range vipTable {
+		// Adapt
+		m := createTimingMem(opcode)
+
+		// Act
+		cycles := m.VIPCycles()
+
+		// Assert
+		assert.Equal(suite.T(), want, cycles, "Cost of %04X", opcode)
+	}
+}
+
+func (suite *TimingTestSuite) TestVIPCycles_Skip() {
+	// Adapt
+	m := createTimingMem(0x3005)
+
+	// Act
+	notTaken := m.VIPCycles()
+	m.V[0] = 5
+	taken := m.VIPCycles()
+
+	// Assert
+	assert.Equal(suite.T(), 40+10, notTaken, "No skip")
+	assert.Equal(suite.T(), 40+14, taken, "Skip taken")
+}
+
+func (suite *TimingTestSuite) TestVIPCycles_KeySkip() {
+	// Adapt
+	m := createTimingMem(0xE09E)
+	m.V[0] = 7
+	m.Input = createKeypad(7)
+
+	// Act
+	cycles := m.VIPCycles()
+
+	// Assert
+	assert.Equal(suite.T(), 40+18, cycles, "Key pressed, skip taken")
+}
+
+func (suite *TimingTestSuite) TestVIPCycles_PageCrossing() {
+	// Adapt
+	jump := createTimingMem(0xB0F0)
+	jump.V[0] = 0x20
+	add := createTimingMem(0xF01E)
+	add.I = 0x2F0
+	add.V[0] = 0x20
+
+	// Act
+	jumpCycles := jump.VIPCycles()
+	addCycles := add.VIPCycles()
+
+	// Assert
+	assert.Equal(suite.T(), 40+24, jumpCycles, "BNNN to the next page")
+	assert.Equal(suite.T(), 40+18, addCycles, "FX1E to the next page")
+}
+
+func (suite *TimingTestSuite) TestVIPCycles_Draw() {
+	// Adapt
+	m := createTimingMem(0xD015)
+
+	// Act
+	aligned := m.VIPCycles()
+	m.V[0] = 3
+	shifted := m.VIPCycles()
+
+	// Assert
+	assert.Equal(suite.T(), 40+26+5*46, aligned, "Rows without shift")
+	assert.Equal(suite.T(), 40+26+5*(46+24), shifted, "Rows shifted by 3 bits")
+	assert.True(suite.T(), m.VIPWaitsDisplay(), "Draw waits for the display")
+}
+
+func (suite *TimingTestSuite) TestVIPCycles_BCD() {
+	// Adapt
+	m := createTimingMem(0xF033)
+	m.V[0] = 123
+
+	// Act
+	cycles := m.VIPCycles()
+
+	// Assert
+	assert.Equal(suite.T(), 40+80+16*6, cycles, "Cost grows with the digits")
+	assert.False(suite.T(), m.VIPWaitsDisplay(), "No display wait")
+}
+
+func TestTimingTestSuite(t *testing.T) {
+	suite.Run(t, new(TimingTestSuite))
+}
@@ -0,0 +1,126 @@
+package chip8
+
+import (
+	"math"
+)
+
+// defaultPitch is the XO-CHIP pitch giving a 4000 Hz playback rate
+const defaultPitch = 64
+
+// fXOChipFunctionMap holds the FX?? opcodes added by the XO-CHIP
+var fXOChipFunctionMap = map[uint16]func(*Memory, uint16) error{0x01: FSelectPlanes, 0x02: FLoadAudioPattern, 0x3A: FSetPitch}
+
+// selectedPlanes returns the bit planes drawn on by the display opcodes
+func (m *Memory) selectedPlanes() [][][]bool {
+	planes := make([][][]bool, 0, 2)
+	if m.Planes&1 != 0 {
+		planes = append(planes, m.Screen)
+	}
+	if m.Planes&2 != 0 {
+		planes = append(planes, m.Screen2)
+	}
+	return planes
+}
+
+// PlaybackRate returns the rate, in bits per second,
+// at which AudioPattern is played
+func (m *Memory) PlaybackRate() float64 {
+	return 4000 * math.Pow(2, (float64(m.Pitch)-64)/48)
+}
+
+// ZeroScrollUp is the 00DN opcode
+// which scrolls the selected planes up by N pixels
+func ZeroScrollUp(m *Memory, opcode uint16) error {
+	for _, plane := range m.selectedPlanes() {
+		scrollVertically(plane, -int(opcode&0x000F))
+	}
+	m.refreshDisplay()
+	m.PC += 2
+	return nil
+}
+
+// FiveSaveRange is the 5XY2 opcode
+// which stores VX to VY in memory starting at address I,
+// I is not modified and the registers are stored backward if X > Y
+func FiveSaveRange(m *Memory, opcode uint16) error {
+	x, y := xyExtractor(opcode)
+	n := registerRangeLength(x, y)
+	if err := m.checkAddress(opcode, m.I, n); err != nil {
+		return err
+	}
+	m.access(m.I, n, true)
+	for i := uint16(0); i < n; i++ {
+		m.Memory[m.I+i] = m.V[registerInRange(x, y, i)]
+	}
+	m.PC += 2
+	return nil
+}
+
+// FiveLoadRange is the 5XY3 opcode
+// which fills VX to VY with values from memory starting at address I,
+// I is not modified and the registers are loaded backward if X > Y
+func FiveLoadRange(m *Memory, opcode uint16) error {
+	x, y := xyExtractor(opcode)
+	n := registerRangeLength(x, y)
+	if err := m.checkAddress(opcode, m.I, n); err != nil {
+		return err
+	}
+	m.access(m.I, n, false)
+	for i := uint16(0); i < n; i++ {
+		m.V[registerInRange(x, y, i)] = m.Memory[m.I+i]
+	}
+	m.PC += 2
+	return nil
+}
+
+// registerRangeLength returns the number of registers from VX to VY
+func registerRangeLength(x, y uint16) uint16 {
+	if x > y {
+		return x - y + 1
+	}
+	return y - x + 1
+}
+
+// registerInRange returns the i-th register going from VX to VY
+func registerInRange(x, y, i uint16) uint16 {
+	if x > y {
+		return x - i
+	}
+	return x + i
+}
+
+// FLongSetAddressRegister is the F000 NNNN opcode
+// which sets I to the 16 bits address NNNN following the opcode
+func FLongSetAddressRegister(m *Memory, opcode uint16) error {
+	if err := m.checkAddress(opcode, m.PC+2, 2); err != nil {
+		return err
+	}
+	m.I = uint16(m.Memory[m.PC+2])<<8 | uint16(m.Memory[m.PC+3])
+	m.PC += 4
+	return nil
+}
+
+// FSelectPlanes is the FN01 opcode
+// which selects the planes drawn on by the display opcodes
+func FSelectPlanes(m *Memory, opcode uint16) error {
+	m.Planes = byte((opcode & 0x0F00) >> 8)
+	return nil
+}
+
+// FLoadAudioPattern is the F002 opcode
+// which loads the 16 bytes at address I in the audio pattern buffer
+func FLoadAudioPattern(m *Memory, opcode uint16) error {
+	if err := m.checkAddress(opcode, m.I, 16); err != nil {
+		return err
+	}
+	m.access(m.I, 16, false)
+	copy(m.AudioPattern[:], m.Memory[m.I:m.I+16])
+	return nil
+}
+
+// FSetPitch is the FX3A opcode
+// which sets the pitch of the audio pattern to VX
+func FSetPitch(m *Memory, opcode uint16) error {
+	m.Pitch = m.V[(opcode&0x0F00)>>8]
+	return nil
+}
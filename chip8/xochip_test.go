@@ -0,0 +1,175 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type XOChipTestSuite struct {
+	suite.Suite
+}
+
+func (suite *XOChipTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+type fakePlaneDisplay struct {
+	fakeDisplay
+	planeDraws int
+}
+
+func (d *fakePlaneDisplay) DrawPlanes(plane1, plane2 [][]bool) {
+	d.planeDraws++
+}
+
+func (suite *XOChipTestSuite) TestMemorySize() {
+	// Adapt
+	xo := NewMemory(QuirksXOCHIP)
+	vip := NewMemory(QuirksVIP)
+	xo.I = 0xFF00
+	vip.I = 0xFF00
+
+	// Act
+	errXO := xo.Decode(0xF065)
+	errVIP := vip.Decode(0xF065)
+
+	// Assert
+	assert.Equal(suite.T(), 0x10000, xo.MemorySize(), "64 KiB")
+	assert.Equal(suite.T(), 0x1000, vip.MemorySize(), "4 KiB")
+	assert.Nil(suite.T(), errXO, "High memory addressable")
+	assert.IsType(suite.T(), &MemoryFaultError{}, errVIP, "High memory not addressable")
+}
+
+func (suite *XOChipTestSuite) TestLongI() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	copy(m.Memory[0x200:], []byte{0xF0, 0x00, 0xAB, 0xCD})
+
+	// Act
+	err := m.Iterate()
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), uint16(0xABCD), m.I, "Long I loaded")
+	assert.Equal(suite.T(), uint16(0x204), m.PC, "4 bytes instruction")
+}
+
+func (suite *XOChipTestSuite) TestSkipOverLongI() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	copy(m.Memory[0x200:], []byte{0x30, 0x00, 0xF0, 0x00, 0xAB, 0xCD})
+
+	// Act
+	m.Iterate()
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x206), m.PC, "Skip the whole long I")
+
+	// Adapt
+	m = NewMemory(QuirksXOCHIP)
+	copy(m.Memory[0x200:], []byte{0xE0, 0xA1, 0xF0, 0x00, 0xAB, 0xCD})
+
+	// Act
+	m.Iterate()
+
+	// Assert
+	assert.Equal(suite.T(), uint16(0x206), m.PC, "Skip the whole long I")
+}
+
+func (suite *XOChipTestSuite) TestSaveLoadRange() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	m.I = 0x300
+	for i := range m.V {
+		m.V[i] = byte(0x10 + i)
+	}
+
+	// Act
+	m.Decode(0x5242)
+	m.Decode(0x5A83)
+
+	// Assert
+	assert.Equal(suite.T(), []byte{0x12, 0x13, 0x14}, m.Memory[0x300:0x303], "V2 to V4 saved")
+	assert.Equal(suite.T(), byte(0x12), m.V[0xA], "VA loaded backward")
+	assert.Equal(suite.T(), byte(0x13), m.V[0x9], "V9 loaded backward")
+	assert.Equal(suite.T(), byte(0x14), m.V[0x8], "V8 loaded backward")
+	assert.Equal(suite.T(), uint16(0x300), m.I, "I unchanged")
+	assert.Equal(suite.T(), uint16(0x204), m.PC, "Move to the next instructions")
+}
+
+func (suite *XOChipTestSuite) TestPlanes() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	d := &fakePlaneDisplay{}
+	m.Display = d
+	m.I = 0x300
+	m.Memory[0x300] = 0x80
+	m.Memory[0x301] = 0x40
+
+	// Act
+	m.Decode(0xF201)
+	m.Decode(0xD001)
+
+	// Assert
+	assert.False(suite.T(), m.Screen[0][0], "Plane 1 not selected")
+	assert.True(suite.T(), m.Screen2[0][0], "Plane 2 drawn")
+
+	// Act
+	m.Decode(0xF301)
+	m.Decode(0xD001)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[0][0], "Plane 1 drawn with the first byte")
+	assert.True(suite.T(), m.Screen2[1][0], "Plane 2 drawn with the second byte")
+	assert.True(suite.T(), m.Screen2[0][0], "Plane 2 untouched")
+	assert.Equal(suite.T(), 2, d.planeDraws, "Both planes sent to the display")
+
+	// Act
+	m.Decode(0xF101)
+	m.Decode(0x00E0)
+
+	// Assert
+	assert.False(suite.T(), m.Screen[0][0], "Plane 1 cleared")
+	assert.True(suite.T(), m.Screen2[0][0], "Plane 2 not cleared")
+}
+
+func (suite *XOChipTestSuite) TestScrollUp() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	m.Screen[3][10] = true
+
+	// Act
+	m.Decode(0x00D4)
+
+	// Assert
+	assert.True(suite.T(), m.Screen[3][6], "Pixel moved up")
+	assert.False(suite.T(), m.Screen[3][10], "Pixel moved")
+}
+
+func (suite *XOChipTestSuite) TestAudio() {
+	// Adapt
+	m := NewMemory(QuirksXOCHIP)
+	m.I = 0x300
+	for i := 0; i < 16; i++ {
+		m.Memory[0x300+i] = byte(i)
+	}
+	m.V[5] = 112
+
+	// Act
+	m.Decode(0xF002)
+	rate := m.PlaybackRate()
+	m.Decode(0xF53A)
+
+	// Assert
+	assert.Equal(suite.T(), byte(15), m.AudioPattern[15], "Pattern loaded")
+	assert.Equal(suite.T(), 4000.0, rate, "Default rate")
+	assert.Equal(suite.T(), byte(112), m.Pitch, "Pitch set")
+	assert.Equal(suite.T(), 8000.0, m.PlaybackRate(), "One octave up")
+}
+
+func TestXOChipTestSuite(t *testing.T) {
+	suite.Run(t, new(XOChipTestSuite))
+}
@@ -0,0 +1,386 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+)
+
+// command runs a debugger command with its arguments and returns its output
+type command func(d *Debugger, args []string) (string, error)
+
+// commands are the debugger commands by name, some have a short alias
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"break":    (*Debugger).breakCommand,
+		"watch":    (*Debugger).watchCommand,
+		"catch":    (*Debugger).catchCommand,
+		"delete":   (*Debugger).deleteCommand,
+		"info":     (*Debugger).infoCommand,
+		"continue": (*Debugger).continueCommand,
+		"step":     (*Debugger).stepCommand,
+		"next":     (*Debugger).nextCommand,
+		"finish":   (*Debugger).finishCommand,
+		"back":     (*Debugger).backCommand,
+		"set":      (*Debugger).setCommand,
+		"print":    (*Debugger).printCommand,
+		"x":        (*Debugger).examineCommand,
+		"poke":     (*Debugger).pokeCommand,
+		"help":     (*Debugger).helpCommand,
+	}
+	for alias, name := range map[string]string{"b": "break", "c": "continue", "s": "step", "n": "next", "p": "print"} {
+		commands[alias] = commands[name]
+	}
+}
+
+// help describes the commands
+var help = []string{
+	"break ADDR [if COND]   stop before ADDR, when COND like V3 == 0x10 holds",
+	"watch ADDR [END] [r|w|rw] stop after an access to ADDR to END",
+	"catch MNEMONIC         stop before the instructions like DRW or CALL",
+	"delete [ID|MNEMONIC]   remove a breakpoint, a watchpoint or a catch, all if none",
+	"info                   list the breakpoints, watchpoints and catches",
+	"continue               run until the program is stopped",
+	"step [N]               execute N instructions",
+	"next                   step over a CALL",
+	"finish                 run until the current subroutine returns",
+	"back                   step back one instruction",
+	"set REG VALUE          change V0-VF, I, PC, SP, DT or ST",
+	"print OPERAND          print a register, a number or a memory byte [ADDR]",
+	"x ADDR [COUNT]         dump COUNT bytes of memory",
+	"poke ADDR BYTE...      write bytes in memory",
+}
+
+// Exec runs a command line and returns its output
+func (d *Debugger) Exec(line string) (string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	c, ok := commands[strings.ToLower(fields[0])]
+	if !ok {
+		return "", fmt.Errorf("unknown command %s, try help", fields[0])
+	}
+	return c(d, fields[1:])
+}
+
+// address parses an address of the addressable memory
+func (d *Debugger) address(s string) (uint16, error) {
+	n, err := parseNumber(s)
+	if err != nil {
+		return 0, err
+	}
+	if n >= d.m.MemorySize() {
+		return 0, fmt.Errorf("address 0x%X out of memory", n)
+	}
+	return uint16(n), nil
+}
+
+func (d *Debugger) breakCommand(args []string) (string, error) {
+	if len(args) != 1 && (len(args) < 5 || args[1] != "if") {
+		return "", errors.New("usage: break ADDR [if COND]")
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return "", err
+	}
+	var c *Condition
+	if len(args) > 1 {
+		if c, err = ParseCondition(strings.Join(args[2:], " ")); err != nil {
+			return "", err
+		}
+	}
+	return describeBreakpoint(d.Break(address, c)), nil
+}
+
+func (d *Debugger) watchCommand(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return "", errors.New("usage: watch ADDR [END] [r|w|rw]")
+	}
+	w := &Watchpoint{Write: true}
+	switch mode := args[len(args)-1]; mode {
+	case "r", "w", "rw":
+		w.Read = strings.Contains(mode, "r")
+		w.Write = strings.Contains(mode, "w")
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		return "", errors.New("usage: watch ADDR [END] [r|w|rw]")
+	}
+	var err error
+	if w.Start, err = d.address(args[0]); err != nil {
+		return "", err
+	}
+	w.End = w.Start
+	if len(args) == 2 {
+		if w.End, err = d.address(args[1]); err != nil {
+			return "", err
+		}
+	}
+	if w.End < w.Start {
+		return "", errors.New("watchpoint ends before its start")
+	}
+	w.ID = d.nextID()
+	d.watchpoints = append(d.watchpoints, w)
+	return describeWatchpoint(w), nil
+}
+
+func (d *Debugger) catchCommand(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: catch MNEMONIC")
+	}
+	name := strings.ToUpper(args[0])
+	d.catches[name] = true
+	return "catch " + name, nil
+}
+
+func (d *Debugger) deleteCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		d.breakpoints = nil
+		d.watchpoints = nil
+		d.catches = make(map[string]bool)
+		return "deleted all", nil
+	}
+	if name := strings.ToUpper(args[0]); d.catches[name] {
+		delete(d.catches, name)
+		return "deleted catch " + name, nil
+	}
+	id, err := parseNumber(args[0])
+	if err != nil {
+		return "", err
+	}
+	for i, b := range d.breakpoints {
+		if b.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return fmt.Sprintf("deleted %d", id), nil
+		}
+	}
+	for i, w := range d.watchpoints {
+		if w.ID == id {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+			return fmt.Sprintf("deleted %d", id), nil
+		}
+	}
+	return "", fmt.Errorf("no breakpoint or watchpoint %d", id)
+}
+
+func (d *Debugger) infoCommand(args []string) (string, error) {
+	var lines []string
+	for _, b := range d.breakpoints {
+		lines = append(lines, describeBreakpoint(b))
+	}
+	for _, w := range d.watchpoints {
+		lines = append(lines, describeWatchpoint(w))
+	}
+	var names []string
+	for name := range d.catches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, "catch "+name)
+	}
+	if len(lines) == 0 {
+		return "no breakpoints", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *Debugger) continueCommand(args []string) (string, error) {
+	d.Resume()
+	return "", nil
+}
+
+func (d *Debugger) stepCommand(args []string) (string, error) {
+	count := 1
+	if len(args) > 0 {
+		var err error
+		if count, err = parseNumber(args[0]); err != nil {
+			return "", err
+		}
+	}
+	var lines []string
+	for i := 0; i < count; i++ {
+		hits, err := d.Step()
+		lines = append(lines, hits...)
+		if err != nil {
+			return strings.Join(lines, "\n"), err
+		}
+	}
+	lines = append(lines, d.decode(d.m.PC).String())
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *Debugger) nextCommand(args []string) (string, error) {
+	in := d.decode(d.m.PC)
+	if in.Flow != disasm.Call {
+		return d.stepCommand(nil)
+	}
+	ret := d.m.PC + uint16(len(in.Bytes))
+	sp := d.m.SP
+	d.Resume()
+	d.until = func(m *chip8.Memory) bool {
+		return m.PC == ret && m.SP == sp
+	}
+	return "", nil
+}
+
+func (d *Debugger) finishCommand(args []string) (string, error) {
+	sp := d.m.SP
+	if sp == 0 {
+		return "", errors.New("not in a subroutine")
+	}
+	d.Resume()
+	d.until = func(m *chip8.Memory) bool {
+		return m.SP < sp
+	}
+	return "", nil
+}
+
+func (d *Debugger) backCommand(args []string) (string, error) {
+	if d.Rewind == nil {
+		return "", errors.New("rewind is disabled")
+	}
+	d.Pause()
+	if err := d.Rewind.StepBack(d.m); err != nil {
+		return "", err
+	}
+	return d.decode(d.m.PC).String(), nil
+}
+
+func (d *Debugger) setCommand(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", errors.New("usage: set REG VALUE")
+	}
+	name := strings.ToUpper(args[0])
+	value, err := parseNumber(args[1])
+	if err != nil {
+		return "", err
+	}
+	m := d.m
+	switch {
+	case len(name) == 2 && name[0] == 'V' && registers[name] != nil:
+		if value > 0xFF {
+			return "", fmt.Errorf("%s holds a byte", name)
+		}
+		m.V[strings.IndexByte("0123456789ABCDEF", name[1])] = byte(value)
+	case name == "I":
+		m.I = uint16(value)
+	case name == "PC":
+		m.PC = uint16(value)
+	case name == "SP":
+		if value > m.StackDepth() {
+			return "", fmt.Errorf("SP is at most %d", m.StackDepth())
+		}
+		m.SP = uint16(value)
+	case name == "DT" || name == "ST":
+		if value > 0xFF {
+			return "", fmt.Errorf("%s holds a byte", name)
+		}
+		if name == "DT" {
+			m.DelayTimer = byte(value)
+		} else {
+			m.SoundTimer = byte(value)
+		}
+	default:
+		return "", fmt.Errorf("unknown register %s", args[0])
+	}
+	return fmt.Sprintf("%s=0x%X", name, value), nil
+}
+
+func (d *Debugger) printCommand(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: print OPERAND")
+	}
+	o, err := parseOperand(args[0])
+	if err != nil {
+		return "", err
+	}
+	value := o.value(d.m)
+	return fmt.Sprintf("%s=0x%X (%d)", o.name, value, value), nil
+}
+
+func (d *Debugger) examineCommand(args []string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", errors.New("usage: x ADDR [COUNT]")
+	}
+	start, err := d.address(args[0])
+	if err != nil {
+		return "", err
+	}
+	count := 16
+	if len(args) == 2 {
+		if count, err = parseNumber(args[1]); err != nil {
+			return "", err
+		}
+	}
+	end := int(start) + count
+	if end > d.m.MemorySize() {
+		end = d.m.MemorySize()
+	}
+	var lines []string
+	for address := int(start); address < end; address += 8 {
+		rowEnd := address + 8
+		if rowEnd > end {
+			rowEnd = end
+		}
+		row := d.m.Memory[address:rowEnd]
+		lines = append(lines, fmt.Sprintf("0x%04X  % X", address, row))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (d *Debugger) pokeCommand(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", errors.New("usage: poke ADDR BYTE...")
+	}
+	start, err := d.address(args[0])
+	if err != nil {
+		return "", err
+	}
+	if int(start)+len(args)-1 > d.m.MemorySize() {
+		return "", errors.New("bytes past the end of memory")
+	}
+	for i, arg := range args[1:] {
+		value, err := parseNumber(arg)
+		if err != nil {
+			return "", err
+		}
+		if value > 0xFF {
+			return "", fmt.Errorf("%s is not a byte", arg)
+		}
+		d.m.Memory[int(start)+i] = byte(value)
+	}
+	return fmt.Sprintf("wrote %d bytes at 0x%04X", len(args)-1, start), nil
+}
+
+func (d *Debugger) helpCommand(args []string) (string, error) {
+	return strings.Join(help, "\n"), nil
+}
+
+// describeBreakpoint returns the line of a breakpoint in info
+func describeBreakpoint(b *Breakpoint) string {
+	s := fmt.Sprintf("breakpoint %d at 0x%04X", b.ID, b.Address)
+	if b.Condition != nil {
+		s += " if " + b.Condition.String()
+	}
+	return s
+}
+
+// describeWatchpoint returns the line of a watchpoint in info
+func describeWatchpoint(w *Watchpoint) string {
+	mode := ""
+	if w.Read {
+		mode += "r"
+	}
+	if w.Write {
+		mode += "w"
+	}
+	return fmt.Sprintf("watchpoint %d %s 0x%04X-0x%04X", w.ID, mode, w.Start, w.End)
+}
@@ -0,0 +1,157 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandsTestSuite struct {
+	suite.Suite
+}
+
+func (suite *CommandsTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *CommandsTestSuite) TestExec_Unknown() {
+	// Act
+	_, err := createDebugger(counter).Exec("jump 0x200")
+
+	// Assert
+	assert.EqualError(suite.T(), err, "unknown command jump, try help", "Unknown command")
+}
+
+func (suite *CommandsTestSuite) TestInfo() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("b 0x204 if V0 >= 2")
+	d.Exec("watch 0x300 0x30F rw")
+	d.Exec("catch drw")
+
+	// Act
+	out, _ := d.Exec("info")
+
+	// Assert
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0204 if V0 >= 2\nwatchpoint 2 rw 0x0300-0x030F\ncatch DRW", out, "All listed")
+}
+
+func (suite *CommandsTestSuite) TestDelete() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x204")
+	d.Exec("watch 0x300")
+	d.Exec("catch DRW")
+
+	// Act
+	d.Exec("delete 1")
+	d.Exec("delete drw")
+	out, _ := d.Exec("info")
+	_, err := d.Exec("delete 1")
+
+	// Assert
+	assert.Equal(suite.T(), "watchpoint 2 w 0x0300-0x0300", out, "Watchpoint left")
+	assert.EqualError(suite.T(), err, "no breakpoint or watchpoint 1", "Already deleted")
+}
+
+func (suite *CommandsTestSuite) TestStep() {
+	// Adapt
+	d := createDebugger(counter)
+
+	// Act
+	out, err := d.Exec("step 3")
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), "0x0202  A300      LD I, 0x300", out, "Next instruction")
+	assert.Equal(suite.T(), byte(1), d.Memory().V[0], "Three instructions executed")
+}
+
+func (suite *CommandsTestSuite) TestStep_Watchpoint() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("watch 0x300")
+	d.Exec("step 4")
+
+	// Act
+	out, _ := d.Exec("step")
+
+	// Assert
+	assert.Equal(suite.T(), "watchpoint 1: write 0x0300-0x0300 by 0x0204\n0x0206  1200      JP 0x200", out, "Hit reported")
+}
+
+func (suite *CommandsTestSuite) TestBack() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Rewind = chip8.NewRewind(1<<20, 16)
+	d.Exec("step 3")
+
+	// Act
+	out, err := d.Exec("back")
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), "0x020A  00EE      RET", out, "Previous instruction")
+	assert.Equal(suite.T(), uint64(2), d.Memory().Cycles, "One instruction back")
+}
+
+func (suite *CommandsTestSuite) TestBack_Disabled() {
+	// Act
+	_, err := createDebugger(counter).Exec("back")
+
+	// Assert
+	assert.EqualError(suite.T(), err, "rewind is disabled", "No rewind")
+}
+
+func (suite *CommandsTestSuite) TestSet() {
+	// Adapt
+	d := createDebugger(counter)
+
+	// Act
+	d.Exec("set VA 0x42")
+	d.Exec("set i 0x300")
+	d.Exec("set PC 0x208")
+	d.Exec("set DT 10")
+	_, err := d.Exec("set V0 0x100")
+	_, err2 := d.Exec("set VX 1")
+
+	// Assert
+	m := d.Memory()
+	assert.Equal(suite.T(), byte(0x42), m.V[0xA], "VA set")
+	assert.Equal(suite.T(), uint16(0x300), m.I, "I set")
+	assert.Equal(suite.T(), uint16(0x208), m.PC, "PC set")
+	assert.Equal(suite.T(), byte(10), m.DelayTimer, "DT set")
+	assert.EqualError(suite.T(), err, "V0 holds a byte", "Byte register")
+	assert.EqualError(suite.T(), err2, "unknown register VX", "Unknown register")
+}
+
+func (suite *CommandsTestSuite) TestPokeExaminePrint() {
+	// Adapt
+	d := createDebugger(counter)
+
+	// Act
+	d.Exec("poke 0x300 1 2 0xFF")
+	dump, _ := d.Exec("x 0x2FE 10")
+	value, _ := d.Exec("print [0x302]")
+	_, err := d.Exec("poke 0x1000 1")
+
+	// Assert
+	assert.Equal(suite.T(), "0x02FE  00 00 01 02 FF 00 00 00\n0x0306  00 00", dump, "Memory dump")
+	assert.Equal(suite.T(), "[0x302]=0xFF (255)", value, "Memory byte")
+	assert.EqualError(suite.T(), err, "address 0x1000 out of memory", "Outside the 4 KiB")
+}
+
+func (suite *CommandsTestSuite) TestFinish_NotInSubroutine() {
+	// Act
+	_, err := createDebugger(counter).Exec("finish")
+
+	// Assert
+	assert.EqualError(suite.T(), err, "not in a subroutine", "Nothing to finish")
+}
+
+func TestCommandsTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandsTestSuite))
+}
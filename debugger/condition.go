@@ -0,0 +1,105 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// operand is a value of the chip8 read by the debugger
+type operand struct {
+	name  string
+	value func(m *chip8.Memory) int
+}
+
+// registers are the operands naming a register, the V registers are added by init
+var registers = map[string]func(m *chip8.Memory) int{
+	"I":  func(m *chip8.Memory) int { return int(m.I) },
+	"PC": func(m *chip8.Memory) int { return int(m.PC) },
+	"SP": func(m *chip8.Memory) int { return int(m.SP) },
+	"DT": func(m *chip8.Memory) int { return int(m.DelayTimer) },
+	"ST": func(m *chip8.Memory) int { return int(m.SoundTimer) },
+}
+
+func init() {
+	for i := 0; i < 0x10; i++ {
+		i := i
+		registers[fmt.Sprintf("V%X", i)] = func(m *chip8.Memory) int { return int(m.V[i]) }
+	}
+}
+
+// parseNumber parses a decimal, 0x hexadecimal or 0b binary number
+func parseNumber(s string) (int, error) {
+	n, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", s)
+	}
+	return int(n), nil
+}
+
+// parseOperand parses a register, a number or a memory byte like [0x300]
+func parseOperand(s string) (operand, error) {
+	if value, ok := registers[strings.ToUpper(s)]; ok {
+		return operand{strings.ToUpper(s), value}, nil
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		address, err := parseNumber(s[1 : len(s)-1])
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{s, func(m *chip8.Memory) int { return int(m.Memory[address]) }}, nil
+	}
+	n, err := parseNumber(s)
+	if err != nil {
+		return operand{}, err
+	}
+	return operand{s, func(m *chip8.Memory) int { return n }}, nil
+}
+
+// comparisons are the operators of the conditions
+var comparisons = map[string]func(a, b int) bool{
+	"==": func(a, b int) bool { return a == b },
+	"!=": func(a, b int) bool { return a != b },
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	">":  func(a, b int) bool { return a > b },
+	">=": func(a, b int) bool { return a >= b },
+}
+
+// Condition compares two operands, like V3 == 0x10 or [0x300] > VA
+type Condition struct {
+	left, right operand
+	op          string
+}
+
+// ParseCondition parses a condition made of two operands and a comparison,
+// the operands are registers, numbers or memory bytes like [0x300]
+func ParseCondition(s string) (*Condition, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("condition must be like V3 == 0x10")
+	}
+	if _, ok := comparisons[fields[1]]; !ok {
+		return nil, fmt.Errorf("unknown comparison %s", fields[1])
+	}
+	left, err := parseOperand(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseOperand(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	return &Condition{left: left, right: right, op: fields[1]}, nil
+}
+
+// Holds tells if the condition is true on m
+func (c *Condition) Holds(m *chip8.Memory) bool {
+	return comparisons[c.op](c.left.value(m), c.right.value(m))
+}
+
+func (c *Condition) String() string {
+	return c.left.name + " " + c.op + " " + c.right.name
+}
@@ -0,0 +1,67 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConditionTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConditionTestSuite) TestHolds() {
+	// Adapt
+	m := chip8.NewSeededMemory(chip8.QuirksVIP, 1)
+	m.V[3] = 0x10
+	m.V[0xA] = 0x20
+	m.I = 0x300
+	m.Memory[0x300] = 0x30
+	expected := map[string]bool{
+		"V3 == 0x10": true, "v3 != 16": false, "VA > V3": true,
+		"[0x300] >= 0x30": true, "I < 0x300": false, "PC <= 0x200": true,
+		"DT == 0": true, "SP == 0": true, "ST == 1": false,
+	}
+
+	for s, holds := range expected {
+		// Act
+		c, err := ParseCondition(s)
+
+		// Assert
+		assert.Nil(suite.T(), err, s)
+		assert.Equal(suite.T(), holds, c.Holds(m), s)
+	}
+}
+
+func (suite *ConditionTestSuite) TestParseCondition_Errors() {
+	// Adapt
+	expected := map[string]string{
+		"V3 = 0x10":   "unknown comparison =",
+		"V3 ==":       "condition must be like V3 == 0x10",
+		"VG == 1":     "invalid number VG",
+		"[0xG] == 1":  "invalid number 0xG",
+		"V3 == 65536": "invalid number 65536",
+	}
+
+	for s, msg := range expected {
+		// Act
+		_, err := ParseCondition(s)
+
+		// Assert
+		assert.EqualError(suite.T(), err, msg, s)
+	}
+}
+
+func (suite *ConditionTestSuite) TestString() {
+	// Act
+	c, _ := ParseCondition("v3  ==   0x10")
+
+	// Assert
+	assert.Equal(suite.T(), "V3 == 0x10", c.String(), "Normalized")
+}
+
+func TestConditionTestSuite(t *testing.T) {
+	suite.Run(t, new(ConditionTestSuite))
+}
@@ -0,0 +1,216 @@
+// Package debugger pauses a running chip8 on breakpoints, watchpoints
+// and caught instructions, it is driven by text commands
+package debugger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+	"github.com/Oicho/GO-Chip8/trace"
+)
+
+// Breakpoint stops the program before the instruction at Address,
+// only when Condition holds if there is one
+type Breakpoint struct {
+	ID        int
+	Address   uint16
+	Condition *Condition
+}
+
+// Watchpoint stops the program after an instruction reading
+// or writing the memory from Start to End included
+type Watchpoint struct {
+	ID         int
+	Start, End uint16
+	Read       bool
+	Write      bool
+}
+
+// Debugger controls a chip8, it is its chip8.Watcher
+type Debugger struct {
+	// Running is set while the program runs,
+	// it is cleared when the debugger stops the program
+	Running bool
+	// Rewind records the program while it runs and steps it back, it can be nil
+	Rewind *chip8.Rewind
+	// Trace records the executed instructions, it can be nil
+	Trace *trace.Recorder
+
+	m           *chip8.Memory
+	breakpoints []*Breakpoint
+	watchpoints []*Watchpoint
+	catches     map[string]bool
+	lastID      int
+
+	// resumed skips the breakpoints of the instruction the program resumes on
+	resumed bool
+	// until stops the program once it holds, it implements next and finish
+	until func(m *chip8.Memory) bool
+	// hits are the watchpoints hit by the last instruction
+	hits []string
+}
+
+// New creates a Debugger controlling m
+func New(m *chip8.Memory) *Debugger {
+	d := &Debugger{catches: make(map[string]bool)}
+	d.Attach(m)
+	return d
+}
+
+// Attach makes the debugger control another chip8,
+// the breakpoints and watchpoints are kept
+func (d *Debugger) Attach(m *chip8.Memory) {
+	d.m = m
+	m.Watcher = d
+	d.until = nil
+	d.resumed = false
+}
+
+// Memory returns the chip8 controlled by the debugger
+func (d *Debugger) Memory() *chip8.Memory {
+	return d.m
+}
+
+// Resume runs the program from the current instruction
+func (d *Debugger) Resume() {
+	d.Running = true
+	d.resumed = true
+}
+
+// Pause stops the program, the pending next and finish are cancelled
+func (d *Debugger) Pause() {
+	d.Running = false
+	d.until = nil
+}
+
+// Break adds a breakpoint at address, the condition can be nil
+func (d *Debugger) Break(address uint16, c *Condition) *Breakpoint {
+	b := &Breakpoint{ID: d.nextID(), Address: address, Condition: c}
+	d.breakpoints = append(d.breakpoints, b)
+	return b
+}
+
+// ClearBreak removes the breakpoints without condition at address,
+// it tells if there was one
+func (d *Debugger) ClearBreak(address uint16) bool {
+	kept := d.breakpoints[:0]
+	for _, b := range d.breakpoints {
+		if b.Address != address || b.Condition != nil {
+			kept = append(kept, b)
+		}
+	}
+	found := len(kept) < len(d.breakpoints)
+	d.breakpoints = kept
+	return found
+}
+
+// Step pauses the program and executes one instruction,
+// it returns the watchpoints hit by the instruction
+func (d *Debugger) Step() ([]string, error) {
+	d.Pause()
+	if d.Rewind != nil {
+		if err := d.Rewind.Snapshot(d.m); err != nil {
+			return nil, err
+		}
+	}
+	return d.execute()
+}
+
+// Iterate executes the next instruction of the running program,
+// it stops the program and returns why when it hits a breakpoint,
+// a watchpoint or a caught instruction or when next or finish are done
+func (d *Debugger) Iterate() (string, error) {
+	if !d.resumed {
+		if stop := d.breakBefore(); stop != "" {
+			d.Pause()
+			return stop, nil
+		}
+	}
+	d.resumed = false
+	if d.Rewind != nil {
+		if err := d.Rewind.Record(d.m); err != nil {
+			d.Pause()
+			return "", fmt.Errorf("rewind snapshot: %w", err)
+		}
+	}
+	hits, err := d.execute()
+	if err != nil {
+		d.Pause()
+		return "", err
+	}
+	if len(hits) > 0 {
+		d.Pause()
+		return strings.Join(hits, "\n"), nil
+	}
+	if d.until != nil && d.until(d.m) {
+		d.Pause()
+		return fmt.Sprintf("stopped at 0x%04X", d.m.PC), nil
+	}
+	return "", nil
+}
+
+// execute runs one instruction and returns the watchpoints it hit
+func (d *Debugger) execute() ([]string, error) {
+	d.hits = nil
+	pc := d.m.PC
+	var err error
+	if d.Trace != nil {
+		err = d.Trace.Step(d.m, d.m.Iterate)
+	} else {
+		err = d.m.Iterate()
+	}
+	hits := d.hits
+	d.hits = nil
+	for i := range hits {
+		hits[i] += fmt.Sprintf(" by 0x%04X", pc)
+	}
+	return hits, err
+}
+
+// breakBefore returns why the program stops before the current instruction
+func (d *Debugger) breakBefore() string {
+	for _, b := range d.breakpoints {
+		if b.Address == d.m.PC && (b.Condition == nil || b.Condition.Holds(d.m)) {
+			return fmt.Sprintf("breakpoint %d at 0x%04X", b.ID, b.Address)
+		}
+	}
+	if len(d.catches) > 0 {
+		in := d.decode(d.m.PC)
+		if name := strings.Fields(in.Mnemonic)[0]; d.catches[name] {
+			return fmt.Sprintf("caught %s at 0x%04X", in.Mnemonic, in.Address)
+		}
+	}
+	return ""
+}
+
+// Access records the watchpoints hit by a memory access,
+// an access of no byte, like a draw without plane, hits none
+func (d *Debugger) Access(address uint16, size uint16, write bool) {
+	if size == 0 {
+		return
+	}
+	end := address + size - 1
+	for _, w := range d.watchpoints {
+		if address > w.End || end < w.Start || write && !w.Write || !write && !w.Read {
+			continue
+		}
+		kind := "read"
+		if write {
+			kind = "write"
+		}
+		d.hits = append(d.hits, fmt.Sprintf("watchpoint %d: %s 0x%04X-0x%04X", w.ID, kind, address, end))
+	}
+}
+
+// decode disassembles the instruction at address
+func (d *Debugger) decode(address uint16) disasm.Instruction {
+	return disasm.Decode(d.m.Memory[:d.m.MemorySize()], address, d.m.Quirks)
+}
+
+// nextID returns the identifier of a new breakpoint or watchpoint
+func (d *Debugger) nextID() int {
+	d.lastID++
+	return d.lastID
+}
@@ -0,0 +1,231 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DebuggerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DebuggerTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createDebugger creates a debugger on a chip8 running the source program
+func createDebugger(src string) *Debugger {
+	rom, err := asm.Assemble("test.asm", []byte(src))
+	if err != nil {
+		panic(err)
+	}
+	m := chip8.NewSeededMemory(chip8.QuirksSCHIP, 1)
+	copy(m.Memory[0x200:], rom)
+	return New(m)
+}
+
+// run iterates the running program until it stops, at most n instructions
+func run(d *Debugger, n int) (string, error) {
+	for i := 0; i < n && d.Running; i++ {
+		if stop, err := d.Iterate(); stop != "" || err != nil {
+			return stop, err
+		}
+	}
+	return "", nil
+}
+
+// counter is a program counting in V0 in a subroutine
+const counter = `
+loop:   CALL incr
+        LD I, 0x300
+        LD [I], V0
+        JP loop
+incr:   ADD V0, 1
+        RET
+`
+
+func (suite *DebuggerTestSuite) TestBreakpoint() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x208")
+	d.Resume()
+
+	// Act
+	stop, err := run(d, 100)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0208", stop, "Stopped on the breakpoint")
+	assert.Equal(suite.T(), uint16(0x208), d.Memory().PC, "Before the instruction")
+	assert.False(suite.T(), d.Running, "Paused")
+}
+
+func (suite *DebuggerTestSuite) TestBreakpoint_Resume() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x208")
+	d.Resume()
+	run(d, 100)
+
+	// Act
+	d.Exec("continue")
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0208", stop, "Stopped on the breakpoint")
+	assert.Equal(suite.T(), byte(1), d.Memory().V[0], "Next loop")
+}
+
+func (suite *DebuggerTestSuite) TestBreakpoint_Condition() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x208 if V0 == 0x10")
+	d.Resume()
+
+	// Act
+	stop, _ := run(d, 1000)
+
+	// Assert
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0208", stop, "Stopped on the breakpoint")
+	assert.Equal(suite.T(), byte(0x10), d.Memory().V[0], "When the condition holds")
+}
+
+func (suite *DebuggerTestSuite) TestWatchpoint_Write() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("watch 0x300")
+	d.Resume()
+
+	// Act
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "watchpoint 1: write 0x0300-0x0300 by 0x0204", stop, "Stopped after the write")
+	assert.Equal(suite.T(), byte(1), d.Memory().Memory[0x300], "Written")
+}
+
+func (suite *DebuggerTestSuite) TestWatchpoint_Read() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("watch 0x2F0 0x30F r")
+	d.Resume()
+
+	// Act
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "", stop, "Writes ignored")
+}
+
+func (suite *DebuggerTestSuite) TestWatchpoint_EmptyAccess() {
+	// Adapt
+	d := createDebugger(counter)
+	_, err := d.Exec("watch 0x100 0x100 r")
+
+	// Act
+	d.Access(0, 0, false)
+	d.Access(0x100, 1, false)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Watchpoint")
+	assert.Equal(suite.T(), []string{"watchpoint 1: read 0x0100-0x0100"}, d.hits, "Only the read of a byte")
+}
+
+func (suite *DebuggerTestSuite) TestCatch() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("catch ret")
+	d.Resume()
+
+	// Act
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "caught RET at 0x020A", stop, "Stopped before RET")
+}
+
+func (suite *DebuggerTestSuite) TestNext() {
+	// Adapt
+	d := createDebugger(counter)
+
+	// Act
+	d.Exec("next")
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "stopped at 0x0202", stop, "After the call")
+	assert.Equal(suite.T(), byte(1), d.Memory().V[0], "Subroutine ran")
+}
+
+func (suite *DebuggerTestSuite) TestNext_Breakpoint() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x208")
+
+	// Act
+	d.Exec("next")
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0208", stop, "Breakpoint in the subroutine")
+}
+
+func (suite *DebuggerTestSuite) TestFinish() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("step")
+
+	// Act
+	d.Exec("finish")
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "stopped at 0x0202", stop, "Back in the caller")
+	assert.Equal(suite.T(), uint16(0), d.Memory().SP, "Returned")
+}
+
+func (suite *DebuggerTestSuite) TestAttach() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Exec("break 0x208")
+	m := chip8.NewSeededMemory(chip8.QuirksSCHIP, 1)
+	copy(m.Memory[0x200:], d.Memory().Memory[0x200:0x20C])
+
+	// Act
+	d.Attach(m)
+	d.Resume()
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.Equal(suite.T(), "breakpoint 1 at 0x0208", stop, "Breakpoints kept")
+	assert.Equal(suite.T(), d, m.Watcher, "Watching the new chip8")
+}
+
+func (suite *DebuggerTestSuite) TestClearBreak() {
+	// Adapt
+	d := createDebugger(counter)
+	d.Break(0x208, nil)
+	c, _ := ParseCondition("V0 == 3")
+	d.Break(0x208, c)
+
+	// Act
+	cleared := d.ClearBreak(0x208)
+	again := d.ClearBreak(0x208)
+	d.Resume()
+	stop, _ := run(d, 100)
+
+	// Assert
+	assert.True(suite.T(), cleared, "Breakpoint removed")
+	assert.False(suite.T(), again, "No breakpoint left")
+	assert.Equal(suite.T(), byte(3), d.Memory().V[0], "Conditional breakpoint kept")
+	assert.Equal(suite.T(), "breakpoint 2 at 0x0208", stop, "Stopped on the conditional breakpoint")
+}
+
+func TestDebuggerTestSuite(t *testing.T) {
+	suite.Run(t, new(DebuggerTestSuite))
+}
@@ -0,0 +1,238 @@
+// Package disasm turns chip8 machine code into readable mnemonics
+package disasm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// Flow tells how an instruction passes the control to the next one
+type Flow int
+
+const (
+	// Next continues with the following instruction
+	Next Flow = iota
+	// Skip may skip the following instruction
+	Skip
+	// Jump continues at Target
+	Jump
+	// Call continues at Target and comes back after the instruction
+	Call
+	// Stop ends the flow (return, exit or computed jump)
+	Stop
+	// Invalid is not an instruction of the platform
+	Invalid
+)
+
+// Instruction is a decoded chip8 instruction
+type Instruction struct {
+	Address  uint16
+	Bytes    []byte
+	Mnemonic string
+	Flow     Flow
+	Target   uint16
+	// Data is set for the bytes never reached by the code
+	Data bool
+}
+
+// String returns the address, raw bytes and mnemonic of the instruction,
+// data bytes come with their bitmap to show the sprites
+func (in Instruction) String() string {
+	line := fmt.Sprintf("0x%04X  %-9X %s", in.Address, in.Bytes, in.Mnemonic)
+	if in.Data && len(in.Bytes) == 1 {
+		bitmap := strings.NewReplacer("0", ".", "1", "#").Replace(fmt.Sprintf("%08b", in.Bytes[0]))
+		line = fmt.Sprintf("%-30s ; %s", line, bitmap)
+	}
+	return line
+}
+
+// Decode decodes the instruction at the given address of a memory image,
+// q selects the SUPER-CHIP and XO-CHIP instructions
+func Decode(mem []byte, address uint16, q chip8.Quirks) Instruction {
+	in := Instruction{Address: address, Bytes: read(mem, address, 2)}
+	if len(in.Bytes) < 2 {
+		in.Mnemonic = db(in.Bytes)
+		in.Flow = Invalid
+		return in
+	}
+	opcode := uint16(in.Bytes[0])<<8 | uint16(in.Bytes[1])
+	if opcode == 0xF000 && q.XOChip {
+		if long := read(mem, address, 4); len(long) == 4 {
+			in.Bytes = long
+			in.Mnemonic = fmt.Sprintf("LD I, 0x%04X", uint16(long[2])<<8|uint16(long[3]))
+			return in
+		}
+	}
+	in.Mnemonic, in.Flow = mnemonic(opcode, q)
+	switch in.Flow {
+	case Jump, Call:
+		in.Target = opcode & 0x0FFF
+	case Invalid:
+		in.Mnemonic = db(in.Bytes)
+	}
+	return in
+}
+
+// read returns up to size bytes of mem starting at address
+func read(mem []byte, address uint16, size int) []byte {
+	start := int(address)
+	if start >= len(mem) {
+		return nil
+	}
+	end := start + size
+	if end > len(mem) {
+		end = len(mem)
+	}
+	return mem[start:end]
+}
+
+// db returns the data directive of raw bytes
+func db(data []byte) string {
+	values := make([]string, len(data))
+	for i, b := range data {
+		values[i] = fmt.Sprintf("0x%02X", b)
+	}
+	return "DB " + strings.Join(values, ", ")
+}
+
+// mnemonic returns the text and control flow of a two bytes opcode
+func mnemonic(opcode uint16, q chip8.Quirks) (string, Flow) {
+	x := opcode >> 8 & 0xF
+	y := opcode >> 4 & 0xF
+	n := opcode & 0xF
+	nn := opcode & 0xFF
+	nnn := opcode & 0xFFF
+	switch opcode >> 12 {
+	case 0x0:
+		return zeroMnemonic(opcode, q)
+	case 0x1:
+		return fmt.Sprintf("JP 0x%03X", nnn), Jump
+	case 0x2:
+		return fmt.Sprintf("CALL 0x%03X", nnn), Call
+	case 0x3:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, nn), Skip
+	case 0x4:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, nn), Skip
+	case 0x5:
+		switch {
+		case n == 0:
+			return fmt.Sprintf("SE V%X, V%X", x, y), Skip
+		case n == 2 && q.XOChip:
+			return fmt.Sprintf("SAVE V%X, V%X", x, y), Next
+		case n == 3 && q.XOChip:
+			return fmt.Sprintf("LOAD V%X, V%X", x, y), Next
+		}
+	case 0x6:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, nn), Next
+	case 0x7:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, nn), Next
+	case 0x8:
+		if op, ok := eightMnemonics[n]; ok {
+			return fmt.Sprintf("%s V%X, V%X", op, x, y), Next
+		}
+	case 0x9:
+		if n == 0 {
+			return fmt.Sprintf("SNE V%X, V%X", x, y), Skip
+		}
+	case 0xA:
+		return fmt.Sprintf("LD I, 0x%03X", nnn), Next
+	case 0xB:
+		if q.JumpVX {
+			return fmt.Sprintf("JP V%X, 0x%03X", x, nnn), Stop
+		}
+		return fmt.Sprintf("JP V0, 0x%03X", nnn), Stop
+	case 0xC:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, nn), Next
+	case 0xD:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n), Next
+	case 0xE:
+		switch nn {
+		case 0x9E:
+			return fmt.Sprintf("SKP V%X", x), Skip
+		case 0xA1:
+			return fmt.Sprintf("SKNP V%X", x), Skip
+		}
+	case 0xF:
+		return fMnemonic(opcode, q)
+	}
+	return "", Invalid
+}
+
+// eightMnemonics are the arithmetic and logic instructions 8XYN
+var eightMnemonics = map[uint16]string{
+	0x0: "LD", 0x1: "OR", 0x2: "AND", 0x3: "XOR",
+	0x4: "ADD", 0x5: "SUB", 0x6: "SHR", 0x7: "SUBN", 0xE: "SHL",
+}
+
+// zeroMnemonic returns the text and control flow of the 0NNN opcodes
+func zeroMnemonic(opcode uint16, q chip8.Quirks) (string, Flow) {
+	switch {
+	case opcode == 0x00E0:
+		return "CLS", Next
+	case opcode == 0x00EE:
+		return "RET", Stop
+	case q.SuperChip && opcode&0xFFF0 == 0x00C0:
+		return fmt.Sprintf("SCD %d", opcode&0xF), Next
+	case q.XOChip && opcode&0xFFF0 == 0x00D0:
+		return fmt.Sprintf("SCU %d", opcode&0xF), Next
+	case q.SuperChip && opcode == 0x00FB:
+		return "SCR", Next
+	case q.SuperChip && opcode == 0x00FC:
+		return "SCL", Next
+	case q.SuperChip && opcode == 0x00FD:
+		return "EXIT", Stop
+	case q.SuperChip && opcode == 0x00FE:
+		return "LOW", Next
+	case q.SuperChip && opcode == 0x00FF:
+		return "HIGH", Next
+	}
+	return fmt.Sprintf("SYS 0x%03X", opcode&0xFFF), Next
+}
+
+// fMnemonic returns the text and control flow of the FXNN opcodes
+func fMnemonic(opcode uint16, q chip8.Quirks) (string, Flow) {
+	x := opcode >> 8 & 0xF
+	switch opcode & 0xFF {
+	case 0x07:
+		return fmt.Sprintf("LD V%X, DT", x), Next
+	case 0x0A:
+		return fmt.Sprintf("LD V%X, K", x), Next
+	case 0x15:
+		return fmt.Sprintf("LD DT, V%X", x), Next
+	case 0x18:
+		return fmt.Sprintf("LD ST, V%X", x), Next
+	case 0x1E:
+		return fmt.Sprintf("ADD I, V%X", x), Next
+	case 0x29:
+		return fmt.Sprintf("LD F, V%X", x), Next
+	case 0x33:
+		return fmt.Sprintf("LD B, V%X", x), Next
+	case 0x55:
+		return fmt.Sprintf("LD [I], V%X", x), Next
+	case 0x65:
+		return fmt.Sprintf("LD V%X, [I]", x), Next
+	}
+	if q.SuperChip {
+		switch opcode & 0xFF {
+		case 0x30:
+			return fmt.Sprintf("LD HF, V%X", x), Next
+		case 0x75:
+			return fmt.Sprintf("LD R, V%X", x), Next
+		case 0x85:
+			return fmt.Sprintf("LD V%X, R", x), Next
+		}
+	}
+	if q.XOChip {
+		switch {
+		case opcode&0xFF == 0x01:
+			return fmt.Sprintf("PLANE %d", x), Next
+		case opcode == 0xF002:
+			return "AUDIO", Next
+		case opcode&0xFF == 0x3A:
+			return fmt.Sprintf("PITCH V%X", x), Next
+		}
+	}
+	return "", Invalid
+}
@@ -0,0 +1,121 @@
+package disasm
+
+import (
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DisasmTestSuite struct {
+	suite.Suite
+}
+
+// decodeOpcode decodes a single opcode at Origin
+func decodeOpcode(opcode uint16, q chip8.Quirks) Instruction {
+	mem := make([]byte, Origin+2)
+	mem[Origin] = byte(opcode >> 8)
+	mem[Origin+1] = byte(opcode)
+	return Decode(mem, Origin, q)
+}
+
+func (suite *DisasmTestSuite) TestDecode_CHIP8() {
+	// Adapt
+	expected := map[uint16]string{
+		0x00E0: "CLS", 0x00EE: "RET", 0x0123: "SYS 0x123",
+		0x1234: "JP 0x234", 0x2345: "CALL 0x345",
+		0x3A42: "SE VA, 0x42", 0x4B42: "SNE VB, 0x42", 0x5120: "SE V1, V2",
+		0x6342: "LD V3, 0x42", 0x7342: "ADD V3, 0x42",
+		0x8120: "LD V1, V2", 0x8121: "OR V1, V2", 0x8122: "AND V1, V2", 0x8123: "XOR V1, V2",
+		0x8124: "ADD V1, V2", 0x8125: "SUB V1, V2", 0x8126: "SHR V1, V2", 0x8127: "SUBN V1, V2",
+		0x812E: "SHL V1, V2", 0x9120: "SNE V1, V2",
+		0xA123: "LD I, 0x123", 0xB123: "JP V0, 0x123", 0xC10F: "RND V1, 0x0F",
+		0xD015: "DRW V0, V1, 5", 0xE19E: "SKP V1", 0xE1A1: "SKNP V1",
+		0xF107: "LD V1, DT", 0xF10A: "LD V1, K", 0xF115: "LD DT, V1", 0xF118: "LD ST, V1",
+		0xF11E: "ADD I, V1", 0xF129: "LD F, V1", 0xF133: "LD B, V1",
+		0xF155: "LD [I], V1", 0xF165: "LD V1, [I]",
+	}
+
+	for opcode, mnemonic := range expected {
+		// Act
+		in := decodeOpcode(opcode, chip8.QuirksVIP)
+
+		// Assert
+		assert.Equal(suite.T(), mnemonic, in.Mnemonic, "Mnemonic of %04X", opcode)
+	}
+}
+
+func (suite *DisasmTestSuite) TestDecode_SuperChip() {
+	// Adapt
+	expected := map[uint16]string{
+		0x00C4: "SCD 4", 0x00FB: "SCR", 0x00FC: "SCL", 0x00FD: "EXIT",
+		0x00FE: "LOW", 0x00FF: "HIGH", 0xF130: "LD HF, V1",
+		0xF175: "LD R, V1", 0xF185: "LD V1, R", 0xB123: "JP V1, 0x123",
+	}
+
+	for opcode, mnemonic := range expected {
+		// Act
+		in := decodeOpcode(opcode, chip8.QuirksSCHIP)
+
+		// Assert
+		assert.Equal(suite.T(), mnemonic, in.Mnemonic, "Mnemonic of %04X", opcode)
+	}
+}
+
+func (suite *DisasmTestSuite) TestDecode_XOChip() {
+	// Adapt
+	expected := map[uint16]string{
+		0x00D4: "SCU 4", 0x5122: "SAVE V1, V2", 0x5123: "LOAD V1, V2",
+		0xF201: "PLANE 2", 0xF002: "AUDIO", 0xF13A: "PITCH V1",
+	}
+
+	for opcode, mnemonic := range expected {
+		// Act
+		in := decodeOpcode(opcode, chip8.QuirksXOCHIP)
+
+		// Assert
+		assert.Equal(suite.T(), mnemonic, in.Mnemonic, "Mnemonic of %04X", opcode)
+	}
+}
+
+func (suite *DisasmTestSuite) TestDecode_LongLoad() {
+	// Adapt
+	mem := []byte{0xF0, 0x00, 0x12, 0x34}
+
+	// Act
+	in := Decode(mem, 0, chip8.QuirksXOCHIP)
+
+	// Assert
+	assert.Equal(suite.T(), "LD I, 0x1234", in.Mnemonic, "Long I")
+	assert.Equal(suite.T(), 4, len(in.Bytes), "Four bytes long")
+}
+
+func (suite *DisasmTestSuite) TestDecode_Invalid() {
+	// Act
+	chip8In := decodeOpcode(0x00FF, chip8.QuirksVIP)
+	invalid := decodeOpcode(0xF1FF, chip8.QuirksXOCHIP)
+
+	// Assert
+	assert.Equal(suite.T(), "SYS 0x0FF", chip8In.Mnemonic, "Not HIGH on CHIP-8")
+	assert.Equal(suite.T(), Invalid, invalid.Flow, "Invalid opcode")
+	assert.Equal(suite.T(), "DB 0xF1, 0xFF", invalid.Mnemonic, "Listed as data")
+}
+
+func (suite *DisasmTestSuite) TestString() {
+	// Adapt
+	in := decodeOpcode(0x6342, chip8.QuirksVIP)
+	data := Instruction{Address: 0x300, Bytes: []byte{0xF0}, Mnemonic: "DB 0xF0", Data: true}
+
+	// Act
+	line := in.String()
+	dataLine := data.String()
+
+	// Assert
+	assert.Equal(suite.T(), "0x0200  6342      LD V3, 0x42", line, "Address, bytes and mnemonic")
+	assert.Equal(suite.T(), "0x0300  F0        DB 0xF0      ; ####....", dataLine, "Sprite bitmap")
+}
+
+func TestDisasmTestSuite(t *testing.T) {
+	suite.Run(t, new(DisasmTestSuite))
+}
@@ -0,0 +1,84 @@
+package disasm
+
+import "github.com/Oicho/GO-Chip8/chip8"
+
+// Origin is the address where the roms are loaded
+const Origin = 0x200
+
+// Listing disassembles a rom loaded at Origin,
+// the code is found by following the control flow from Origin
+// and everything it does not reach is listed as data bytes
+func Listing(rom []byte, q chip8.Quirks) []Instruction {
+	mem := make([]byte, Origin+len(rom))
+	copy(mem[Origin:], rom)
+	starts := trace(mem, q)
+	var listing []Instruction
+	for address := Origin; address < len(mem); {
+		if starts[uint16(address)] {
+			in := Decode(mem, uint16(address), q)
+			listing = append(listing, in)
+			address += len(in.Bytes)
+			continue
+		}
+		listing = append(listing, Instruction{
+			Address:  uint16(address),
+			Bytes:    mem[address : address+1],
+			Mnemonic: db(mem[address : address+1]),
+			Flow:     Invalid,
+			Data:     true,
+		})
+		address++
+	}
+	return listing
+}
+
+// trace returns the addresses of the instructions reachable from Origin,
+// computed jumps (BNNN) cannot be followed and end their path
+func trace(mem []byte, q chip8.Quirks) map[uint16]bool {
+	starts := make(map[uint16]bool)
+	pending := []uint16{Origin}
+	for len(pending) > 0 {
+		address := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if starts[address] || int(address) < Origin || int(address)+1 >= len(mem) {
+			continue
+		}
+		in := Decode(mem, address, q)
+		if in.Flow == Invalid {
+			continue
+		}
+		starts[address] = true
+		next := address + uint16(len(in.Bytes))
+		switch in.Flow {
+		case Next:
+			pending = append(pending, next)
+		case Skip:
+			pending = append(pending, next, next+uint16(len(Decode(mem, next, q).Bytes)))
+		case Jump:
+			pending = append(pending, in.Target)
+		case Call:
+			pending = append(pending, next, in.Target)
+		}
+	}
+	return starts
+}
+
+// Around decodes the instructions of a memory image around pc,
+// before instructions preceding it and after following it.
+// The code is variable length on XO-CHIP so the preceding instructions
+// are guessed two bytes each.
+func Around(mem []byte, pc uint16, before, after int, q chip8.Quirks) []Instruction {
+	var listing []Instruction
+	for i := before; i > 0; i-- {
+		if int(pc) >= 2*i {
+			listing = append(listing, Decode(mem, pc-uint16(2*i), q))
+		}
+	}
+	address := int(pc)
+	for i := 0; i <= after && address < len(mem); i++ {
+		in := Decode(mem, uint16(address), q)
+		listing = append(listing, in)
+		address += len(in.Bytes)
+	}
+	return listing
+}
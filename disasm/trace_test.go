@@ -0,0 +1,104 @@
+package disasm
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TraceTestSuite struct {
+	suite.Suite
+}
+
+// mnemonics returns the mnemonics of a listing
+func mnemonics(listing []Instruction) []string {
+	var result []string
+	for _, in := range listing {
+		result = append(result, in.Mnemonic)
+	}
+	return result
+}
+
+func (suite *TraceTestSuite) TestListing_SpriteData() {
+	// Adapt
+	rom := []byte{
+		0xA2, 0x06, // LD I, 0x206
+		0xD0, 0x12, // DRW V0, V1, 2
+		0x12, 0x04, // JP 0x204
+		0xF0, 0x90, // sprite
+	}
+
+	// Act
+	listing := Listing(rom, chip8.QuirksVIP)
+
+	// Assert
+	assert.Equal(suite.T(), []string{"LD I, 0x206", "DRW V0, V1, 2", "JP 0x204", "DB 0xF0", "DB 0x90"}, mnemonics(listing), "Sprite after the loop")
+	assert.True(suite.T(), listing[3].Data, "Sprite is data")
+}
+
+func (suite *TraceTestSuite) TestListing_CallAndSkip() {
+	// Adapt
+	rom := []byte{
+		0x22, 0x08, // CALL 0x208
+		0x30, 0x01, // SE V0, 0x01
+		0x12, 0x02, // JP 0x202
+		0x00, 0xE0, // CLS, reached by the skip
+		0x60, 0x01, // LD V0, 0x01
+		0x00, 0xEE, // RET
+		0xFF, 0xFF, // unreached
+	}
+
+	// Act
+	listing := Listing(rom, chip8.QuirksVIP)
+
+	// Assert
+	assert.Equal(suite.T(), []string{
+		"CALL 0x208", "SE V0, 0x01", "JP 0x202", "CLS", "LD V0, 0x01", "RET", "DB 0xFF", "DB 0xFF",
+	}, mnemonics(listing), "Code reached by call, return and skip")
+}
+
+func (suite *TraceTestSuite) TestListing_SkipLongLoad() {
+	// Adapt
+	rom := []byte{
+		0x30, 0x01, // SE V0, 0x01
+		0xF0, 0x00, 0x12, 0x34, // LD I, 0x1234
+		0x00, 0xFD, // EXIT
+	}
+
+	// Act
+	listing := Listing(rom, chip8.QuirksXOCHIP)
+
+	// Assert
+	assert.Equal(suite.T(), []string{"SE V0, 0x01", "LD I, 0x1234", "EXIT"}, mnemonics(listing), "Skip over four bytes")
+}
+
+func (suite *TraceTestSuite) TestListing_Pong() {
+	// Adapt
+	rom, _ := ioutil.ReadFile("../rom/PONG")
+
+	// Act
+	listing := Listing(rom, chip8.QuirksVIP)
+
+	// Assert
+	assert.Equal(suite.T(), "LD VA, 0x02", listing[0].Mnemonic, "First instruction")
+	assert.True(suite.T(), listing[len(listing)-1].Data, "Ends with the sprites")
+}
+
+func (suite *TraceTestSuite) TestAround() {
+	// Adapt
+	mem := []byte{0x00, 0xE0, 0x60, 0x01, 0x61, 0x02, 0x62, 0x03}
+
+	// Act
+	listing := Around(mem, 4, 2, 1, chip8.QuirksVIP)
+
+	// Assert
+	assert.Equal(suite.T(), []string{"CLS", "LD V0, 0x01", "LD V1, 0x02", "LD V2, 0x03"}, mnemonics(listing), "Two before, one after")
+	assert.Equal(suite.T(), uint16(4), listing[2].Address, "PC")
+}
+
+func TestTraceTestSuite(t *testing.T) {
+	suite.Run(t, new(TraceTestSuite))
+}
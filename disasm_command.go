@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+)
+
+// disasmCommand prints the listing of a rom
+func disasmCommand(args []string) error {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	quirksName := flags.String("quirks", "vip", "platform of the rom: "+strings.Join(chip8.QuirksNames(), ", "))
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: program disasm [options] filepath")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	quirks, ok := chip8.QuirksByName(*quirksName)
+	if !ok {
+		return fmt.Errorf("unknown quirks profile: %s", *quirksName)
+	}
+	rom, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, in := range disasm.Listing(rom, quirks) {
+		fmt.Println(in)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gdbstub
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// interrupt is the byte sent by the client to stop the running program
+const interrupt = 0x03
+
+// errChecksum is returned for a packet received with a wrong checksum
+var errChecksum = errors.New("gdbstub: bad checksum")
+
+// checksum returns the modulo 256 sum of the packet data
+func checksum(data string) byte {
+	var sum byte
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	return sum
+}
+
+// encode frames packet data as $data#checksum
+func encode(data string) string {
+	return fmt.Sprintf("$%s#%02x", data, checksum(data))
+}
+
+// readPacket reads the next packet of the client, skipping the acknowledgments.
+// An interrupt is returned as a packet made of the interrupt byte.
+func readPacket(r *bufio.Reader) (string, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case interrupt:
+			return string([]byte{interrupt}), nil
+		case '$':
+		default:
+			continue
+		}
+		data, err := r.ReadString('#')
+		if err != nil {
+			return "", err
+		}
+		data = data[:len(data)-1]
+		var sum [2]byte
+		if _, err := io.ReadFull(r, sum[:]); err != nil {
+			return "", err
+		}
+		var expected byte
+		if _, err := fmt.Sscanf(string(sum[:]), "%02x", &expected); err != nil || expected != checksum(data) {
+			return data, errChecksum
+		}
+		return unescape(data), nil
+	}
+}
+
+// unescape decodes the bytes escaped with } in the binary packets
+func unescape(data string) string {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '}' && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^0x20)
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return string(out)
+}
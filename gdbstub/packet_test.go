@@ -0,0 +1,75 @@
+package gdbstub
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PacketTestSuite struct {
+	suite.Suite
+}
+
+// reader reads a raw client stream
+func reader(s string) *bufio.Reader {
+	return bufio.NewReader(strings.NewReader(s))
+}
+
+func (suite *PacketTestSuite) TestEncode() {
+	// Act
+	packet := encode("OK")
+
+	// Assert
+	assert.Equal(suite.T(), "$OK#9a", packet, "Data and checksum")
+}
+
+func (suite *PacketTestSuite) TestReadPacket() {
+	// Adapt
+	r := reader("++$g#67$m200,2#5d")
+
+	// Act
+	first, err := readPacket(r)
+	second, err2 := readPacket(r)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Nil(suite.T(), err2, "No error")
+	assert.Equal(suite.T(), "g", first, "Acknowledgments skipped")
+	assert.Equal(suite.T(), "m200,2", second, "Second packet")
+}
+
+func (suite *PacketTestSuite) TestReadPacket_Interrupt() {
+	// Act
+	data, err := readPacket(reader("\x03"))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), "\x03", data, "Interrupt")
+}
+
+func (suite *PacketTestSuite) TestReadPacket_BadChecksum() {
+	// Act
+	_, err := readPacket(reader("$g#00"))
+
+	// Assert
+	assert.Equal(suite.T(), errChecksum, err, "Checksum checked")
+}
+
+func (suite *PacketTestSuite) TestReadPacket_Escaped() {
+	// Adapt
+	data := "X200,1:}\x03"
+
+	// Act
+	packet, err := readPacket(reader(encode(data)))
+
+	// Assert
+	assert.Nil(suite.T(), err, "No error")
+	assert.Equal(suite.T(), "X200,1:#", packet, "Escaped byte decoded")
+}
+
+func TestPacketTestSuite(t *testing.T) {
+	suite.Run(t, new(PacketTestSuite))
+}
@@ -0,0 +1,347 @@
+// Package gdbstub serves a chip8 to the clients of the GDB remote serial protocol,
+// like gdb with "target remote". The program runs under a debugger.Debugger
+// and the packets are handled in the loop running it.
+package gdbstub
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/debugger"
+)
+
+// request is a packet received from a client, or the end of its connection
+type request struct {
+	conn   net.Conn
+	data   string
+	closed bool
+}
+
+// Server is a GDB remote serial protocol stub, one client is served at a time.
+// The connections are read in the background and the packets wait for Service.
+type Server struct {
+	d        *debugger.Debugger
+	listener net.Listener
+	requests chan request
+	// done is closed by Close to stop the connections
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// mu is held by Service and Close, which can run in different goroutines
+	mu sync.Mutex
+	// conn is the client of the last packet
+	conn net.Conn
+	// waiting is set while a continue waits for the program to stop
+	waiting bool
+	// interrupted is set when the client stopped the program
+	interrupted bool
+}
+
+// Listen serves the chip8 controlled by d on a TCP address like localhost:1234
+func Listen(address string, d *debugger.Debugger) (*Server, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{d: d, listener: l, requests: make(chan request, 16), done: make(chan struct{})}
+	go s.accept()
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Close stops listening and disconnects the clients,
+// the packets not serviced yet are dropped
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	return s.listener.Close()
+}
+
+// accept serves the clients one after the other
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.serve(conn)
+	}
+}
+
+// serve acknowledges the packets of a client and queues them for Service
+func (s *Server) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		data, err := readPacket(r)
+		if err == errChecksum {
+			conn.Write([]byte("-"))
+			continue
+		}
+		if err != nil {
+			conn.Close()
+			s.queue(request{conn: conn, closed: true})
+			return
+		}
+		if data == "" || data[0] != interrupt {
+			conn.Write([]byte("+"))
+		}
+		if !s.queue(request{conn: conn, data: data}) {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// queue waits for Service to take the request, it returns false once the server is closed
+func (s *Server) queue(req request) bool {
+	select {
+	case s.requests <- req:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Service handles the packets received since the last call
+// and tells the client when the program it continued has stopped.
+// It must be called regularly by the loop running the debugger,
+// it returns true if the chip8 was changed.
+func (s *Server) Service() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for {
+		select {
+		case req := <-s.requests:
+			if req.closed {
+				if s.conn == req.conn {
+					s.conn = nil
+					s.waiting = false
+				}
+				continue
+			}
+			s.conn = req.conn
+			changed = s.handle(req.data) || changed
+		default:
+			if s.waiting && !s.d.Running {
+				s.waiting = false
+				s.reply(s.stopReply())
+			}
+			return changed
+		}
+	}
+}
+
+// reply sends a packet to the client
+func (s *Server) reply(data string) {
+	if s.conn != nil {
+		s.conn.Write([]byte(encode(data)))
+	}
+}
+
+// stopReply returns why the program is stopped
+func (s *Server) stopReply() string {
+	switch {
+	case s.d.Memory().Halted:
+		return "W00"
+	case s.interrupted:
+		s.interrupted = false
+		return "S02"
+	}
+	return "S05"
+}
+
+// handle executes a packet and replies to it, it tells if the chip8 was changed
+func (s *Server) handle(data string) bool {
+	m := s.d.Memory()
+	switch {
+	case data == "":
+		s.reply("")
+	case data[0] == interrupt:
+		if s.d.Running {
+			s.d.Pause()
+			s.interrupted = true
+		}
+		return false
+	case data == "?":
+		s.reply(s.stopReply())
+	case strings.HasPrefix(data, "qSupported"):
+		s.reply("PacketSize=1000;qXfer:features:read+")
+	case strings.HasPrefix(data, "qXfer:features:read:target.xml:"):
+		s.reply(s.readTarget(strings.TrimPrefix(data, "qXfer:features:read:target.xml:")))
+	case data == "qAttached":
+		s.reply("1")
+	case data == "qC":
+		s.reply("QC1")
+	case data == "qfThreadInfo":
+		s.reply("m1")
+	case data == "qsThreadInfo":
+		s.reply("l")
+	case data[0] == 'H':
+		s.reply("OK")
+	case data == "g":
+		s.reply(readRegisters(m))
+	case data[0] == 'G':
+		return s.replyError(writeRegisters(m, data[1:]))
+	case data[0] == 'p':
+		n, err := strconv.ParseUint(data[1:], 16, 8)
+		if err != nil || int(n) >= len(registers) {
+			s.reply("E01")
+			break
+		}
+		s.reply(encodeRegister(m, registers[n]))
+	case data[0] == 'P':
+		return s.writeRegister(m, data[1:])
+	case data[0] == 'm':
+		s.reply(s.readMemory(m, data[1:]))
+	case data[0] == 'M':
+		return s.writeMemory(m, data[1:])
+	case strings.HasPrefix(data, "Z0,") || strings.HasPrefix(data, "z0,"):
+		s.breakpoint(m, data)
+	case data[0] == 'c':
+		if !s.jump(m, data[1:]) {
+			break
+		}
+		s.interrupted = false
+		s.waiting = true
+		s.d.Resume()
+		return true
+	case data[0] == 's':
+		if !s.jump(m, data[1:]) {
+			break
+		}
+		s.d.Step()
+		s.reply(s.stopReply())
+		return true
+	case data == "D":
+		s.reply("OK")
+		s.conn.Close()
+		s.d.Resume()
+		return true
+	case data == "k":
+		s.conn.Close()
+	default:
+		s.reply("")
+	}
+	return false
+}
+
+// replyError replies OK or an error, it tells if the command succeeded
+func (s *Server) replyError(err error) bool {
+	if err != nil {
+		s.reply("E01")
+		return false
+	}
+	s.reply("OK")
+	return true
+}
+
+// readTarget returns a chunk of the target description
+func (s *Server) readTarget(args string) string {
+	var offset, length int
+	if _, err := fmt.Sscanf(args, "%x,%x", &offset, &length); err != nil {
+		return "E01"
+	}
+	if offset >= len(targetXML) {
+		return "l"
+	}
+	if offset+length >= len(targetXML) {
+		return "l" + targetXML[offset:]
+	}
+	return "m" + targetXML[offset:offset+length]
+}
+
+// writeRegister sets one register from a P packet
+func (s *Server) writeRegister(m *chip8.Memory, args string) bool {
+	parts := strings.SplitN(args, "=", 2)
+	n, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || len(parts) != 2 || int(n) >= len(registers) {
+		s.reply("E01")
+		return false
+	}
+	return s.replyError(writeRegister(m, registers[n], parts[1]))
+}
+
+// memoryRange parses the addr,length of the m and M packets,
+// the range must be in the addressable memory
+func memoryRange(m *chip8.Memory, args string) (int, int, bool) {
+	var address, length int
+	if _, err := fmt.Sscanf(args, "%x,%x", &address, &length); err != nil {
+		return 0, 0, false
+	}
+	if address < 0 || length < 0 || address+length > m.MemorySize() {
+		return 0, 0, false
+	}
+	return address, length, true
+}
+
+// readMemory returns the bytes of a m packet
+func (s *Server) readMemory(m *chip8.Memory, args string) string {
+	address, length, ok := memoryRange(m, args)
+	if !ok {
+		return "E01"
+	}
+	return hex.EncodeToString(m.Memory[address : address+length])
+}
+
+// writeMemory writes the bytes of a M packet
+func (s *Server) writeMemory(m *chip8.Memory, args string) bool {
+	parts := strings.SplitN(args, ":", 2)
+	address, length, ok := memoryRange(m, parts[0])
+	if !ok || len(parts) != 2 {
+		s.reply("E01")
+		return false
+	}
+	data, err := hex.DecodeString(parts[1])
+	if err != nil || len(data) != length {
+		s.reply("E01")
+		return false
+	}
+	copy(m.Memory[address:], data)
+	s.reply("OK")
+	return true
+}
+
+// breakpoint adds or removes a breakpoint for the Z0 and z0 packets
+func (s *Server) breakpoint(m *chip8.Memory, data string) {
+	var address, kind int
+	if _, err := fmt.Sscanf(data[3:], "%x,%x", &address, &kind); err != nil || address >= m.MemorySize() {
+		s.reply("E01")
+		return
+	}
+	if data[0] == 'Z' {
+		s.d.Break(uint16(address), nil)
+	} else {
+		s.d.ClearBreak(uint16(address))
+	}
+	s.reply("OK")
+}
+
+// jump sets the PC to the optional address of the c and s packets
+func (s *Server) jump(m *chip8.Memory, args string) bool {
+	if args == "" {
+		return true
+	}
+	address, err := strconv.ParseUint(args, 16, 16)
+	if err != nil || int(address) >= m.MemorySize() {
+		s.reply("E01")
+		return false
+	}
+	m.PC = uint16(address)
+	return true
+}
@@ -0,0 +1,236 @@
+package gdbstub
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/debugger"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerTestSuite struct {
+	suite.Suite
+	server *Server
+	conn   net.Conn
+	r      *bufio.Reader
+	done   chan bool
+}
+
+// program counts in V0 in a subroutine
+const program = `
+loop:   CALL incr
+        JP loop
+incr:   ADD V0, 1
+        RET
+`
+
+// SetupTest runs a chip8 under a debugger served on a local port,
+// the loop runs like the one of main
+func (suite *ServerTestSuite) SetupTest() {
+	myLogger.Init(true)
+	rom, _ := asm.Assemble("test.asm", []byte(program))
+	m := chip8.NewSeededMemory(chip8.QuirksVIP, 1)
+	copy(m.Memory[0x200:], rom)
+	d := debugger.New(m)
+	suite.server, _ = Listen("127.0.0.1:0", d)
+	suite.done = make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-suite.done:
+				return
+			default:
+			}
+			if d.Running {
+				d.Iterate()
+			}
+			suite.server.Service()
+		}
+	}()
+	suite.conn, _ = net.Dial("tcp", suite.server.Addr().String())
+	suite.r = bufio.NewReader(suite.conn)
+}
+
+func (suite *ServerTestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.done <- true
+	suite.server.Close()
+}
+
+// send sends a packet and returns the reply
+func (suite *ServerTestSuite) send(data string) string {
+	suite.conn.Write([]byte(encode(data)))
+	reply, err := readPacket(suite.r)
+	assert.Nil(suite.T(), err, "Reply to "+data)
+	return reply
+}
+
+func (suite *ServerTestSuite) TestRegisters() {
+	// Act
+	status := suite.send("?")
+	registers := suite.send("g")
+
+	// Assert
+	assert.Equal(suite.T(), "S05", status, "Stopped")
+	assert.Equal(suite.T(), strings.Repeat("00", 16)+"0000"+"0200"+"0000"+"00"+"00", registers, "V0-VF, I, PC, SP, DT, ST")
+}
+
+func (suite *ServerTestSuite) TestWriteRegisters() {
+	// Adapt
+	registers := "42" + strings.Repeat("00", 15) + "0300" + "0204" + "0000" + "05" + "06"
+
+	// Act
+	ok := suite.send("G" + registers)
+	read := suite.send("g")
+	pc := suite.send("p11")
+	okP := suite.send("P3=7f")
+	v3 := suite.send("p3")
+
+	// Assert
+	assert.Equal(suite.T(), "OK", ok, "Written")
+	assert.Equal(suite.T(), registers, read, "Read back")
+	assert.Equal(suite.T(), "0204", pc, "PC register")
+	assert.Equal(suite.T(), "OK", okP, "Written")
+	assert.Equal(suite.T(), "7f", v3, "V3 register")
+}
+
+func (suite *ServerTestSuite) TestWriteRegisters_Invalid() {
+	// Adapt
+	before := suite.send("g")
+	badSP := "42" + strings.Repeat("00", 15) + "0300" + "0204" + "ffff" + "05" + "06"
+	badST := "42" + strings.Repeat("00", 15) + "0300" + "0204" + "0000" + "05" + "zz"
+
+	// Act
+	errSP := suite.send("G" + badSP)
+	errST := suite.send("G" + badST)
+	errP := suite.send("P12=ffff")
+	after := suite.send("g")
+
+	// Assert
+	assert.Equal(suite.T(), "E01", errSP, "SP past the stack")
+	assert.Equal(suite.T(), "E01", errST, "Bad hexadecimal")
+	assert.Equal(suite.T(), "E01", errP, "SP past the stack")
+	assert.Equal(suite.T(), before, after, "Nothing written")
+}
+
+func (suite *ServerTestSuite) TestMemory() {
+	// Act
+	code := suite.send("m200,2")
+	ok := suite.send("M300,2:beef")
+	written := suite.send("m300,2")
+	outside := suite.send("m fff,2")
+
+	// Assert
+	assert.Equal(suite.T(), "2204", code, "CALL incr")
+	assert.Equal(suite.T(), "OK", ok, "Written")
+	assert.Equal(suite.T(), "beef", written, "Read back")
+	assert.Equal(suite.T(), "E01", outside, "Past the 4 KiB")
+}
+
+func (suite *ServerTestSuite) TestBreakpointContinue() {
+	// Adapt
+	suite.send("Z0,204,2")
+
+	// Act
+	first := suite.send("c")
+	v0 := suite.send("p0")
+	second := suite.send("c")
+	v0Again := suite.send("p0")
+	suite.send("z0,204,2")
+	suite.send("Z0,202,2")
+	third := suite.send("c")
+	pc := suite.send("p11")
+
+	// Assert
+	assert.Equal(suite.T(), "S05", first, "Stopped on the breakpoint")
+	assert.Equal(suite.T(), "00", v0, "Before ADD")
+	assert.Equal(suite.T(), "S05", second, "Stopped again")
+	assert.Equal(suite.T(), "01", v0Again, "One loop")
+	assert.Equal(suite.T(), "S05", third, "Stopped on the new breakpoint")
+	assert.Equal(suite.T(), "0202", pc, "After RET")
+}
+
+func (suite *ServerTestSuite) TestStep() {
+	// Act
+	stop := suite.send("s")
+	pc := suite.send("p11")
+
+	// Assert
+	assert.Equal(suite.T(), "S05", stop, "Stepped")
+	assert.Equal(suite.T(), "0204", pc, "In the subroutine")
+}
+
+func (suite *ServerTestSuite) TestInterrupt() {
+	// Adapt
+	suite.conn.Write([]byte(encode("c")))
+
+	// Act
+	suite.conn.Write([]byte{interrupt})
+	stop, _ := readPacket(suite.r)
+
+	// Assert
+	assert.Equal(suite.T(), "S02", stop, "Interrupted")
+}
+
+func (suite *ServerTestSuite) TestTargetDescription() {
+	// Act
+	supported := suite.send("qSupported:xmlRegisters=i386")
+	xml := suite.send("qXfer:features:read:target.xml:0,fff")
+	unknown := suite.send("vMustReplyEmpty")
+
+	// Assert
+	assert.Contains(suite.T(), supported, "qXfer:features:read+", "Target description supported")
+	assert.True(suite.T(), strings.HasPrefix(xml, "l<?xml"), "Whole description")
+	assert.Contains(suite.T(), xml, "<architecture>chip8</architecture>", "Architecture")
+	assert.Contains(suite.T(), xml, `<reg name="pc" bitsize="16" type="code_ptr" regnum="17"/>`, "PC register")
+	assert.Contains(suite.T(), xml, `<reg name="st" bitsize="8" type="uint8" regnum="20"/>`, "Sound timer")
+	assert.Equal(suite.T(), "", unknown, "Unsupported packet")
+}
+
+func (suite *ServerTestSuite) TestStatus_Halted() {
+	// Adapt
+	m := chip8.NewSeededMemory(chip8.QuirksSCHIP, 1)
+	m.Halted = true
+	client, conn := net.Pipe()
+	defer client.Close()
+	s := &Server{d: debugger.New(m), conn: conn}
+
+	// Act
+	go s.handle("?")
+	status, err := readPacket(bufio.NewReader(client))
+
+	// Assert
+	assert.Nil(suite.T(), err, "Reply")
+	assert.Equal(suite.T(), "W00", status, "Program exited")
+}
+
+func (suite *ServerTestSuite) TestClose_Unserviced() {
+	// Adapt
+	s, _ := Listen("127.0.0.1:0", debugger.New(chip8.NewSeededMemory(chip8.QuirksVIP, 1)))
+	conn, _ := net.Dial("tcp", s.Addr().String())
+	defer conn.Close()
+	for i := 0; i < 2*cap(s.requests); i++ {
+		conn.Write([]byte(encode("g")))
+	}
+
+	// Act
+	time.Sleep(10 * time.Millisecond)
+	s.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err := ioutil.ReadAll(conn)
+
+	// Assert
+	assert.Nil(suite.T(), err, "Client disconnected instead of waiting for Service")
+}
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
@@ -0,0 +1,156 @@
+package gdbstub
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// register is a register of the target description,
+// check rejects the values it can not hold and can be nil
+type register struct {
+	name  string
+	size  int
+	get   func(m *chip8.Memory) uint16
+	set   func(m *chip8.Memory, value uint16)
+	check func(m *chip8.Memory, value uint16) error
+}
+
+// registers are V0 to VF, I, PC, SP, DT and ST in the order of the g packet,
+// their bytes are big-endian like the chip8 memory
+var registers = newRegisters()
+
+// newRegisters returns the registers of the target description
+func newRegisters() []register {
+	var registers []register
+	for i := 0; i < 0x10; i++ {
+		i := i
+		registers = append(registers, register{
+			fmt.Sprintf("v%x", i), 1,
+			func(m *chip8.Memory) uint16 { return uint16(m.V[i]) },
+			func(m *chip8.Memory, v uint16) { m.V[i] = byte(v) },
+			nil,
+		})
+	}
+	registers = append(registers,
+		register{"i", 2,
+			func(m *chip8.Memory) uint16 { return m.I },
+			func(m *chip8.Memory, v uint16) { m.I = v },
+			nil},
+		register{"pc", 2,
+			func(m *chip8.Memory) uint16 { return m.PC },
+			func(m *chip8.Memory, v uint16) { m.PC = v },
+			nil},
+		register{"sp", 2,
+			func(m *chip8.Memory) uint16 { return m.SP },
+			func(m *chip8.Memory, v uint16) { m.SP = v },
+			func(m *chip8.Memory, v uint16) error {
+				if int(v) > m.StackDepth() {
+					return fmt.Errorf("gdbstub: sp 0x%x past the stack of %d", v, m.StackDepth())
+				}
+				return nil
+			}},
+		register{"dt", 1,
+			func(m *chip8.Memory) uint16 { return uint16(m.DelayTimer) },
+			func(m *chip8.Memory, v uint16) { m.DelayTimer = byte(v) },
+			nil},
+		register{"st", 1,
+			func(m *chip8.Memory) uint16 { return uint16(m.SoundTimer) },
+			func(m *chip8.Memory, v uint16) { m.SoundTimer = byte(v) },
+			nil},
+	)
+	return registers
+}
+
+// targetXML is the target description sent to the client
+var targetXML = func() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?>` + "\n")
+	b.WriteString(`<!DOCTYPE target SYSTEM "gdb-target.dtd">` + "\n")
+	b.WriteString(`<target version="1.0">` + "\n")
+	b.WriteString("  <architecture>chip8</architecture>\n")
+	b.WriteString(`  <feature name="org.chip8.core">` + "\n")
+	for n, r := range registers {
+		kind := fmt.Sprintf("uint%d", 8*r.size)
+		switch r.name {
+		case "i":
+			kind = "data_ptr"
+		case "pc":
+			kind = "code_ptr"
+		}
+		fmt.Fprintf(&b, `    <reg name="%s" bitsize="%d" type="%s" regnum="%d"/>`+"\n", r.name, 8*r.size, kind, n)
+	}
+	b.WriteString("  </feature>\n</target>\n")
+	return b.String()
+}()
+
+// encodeRegister returns the hexadecimal value of a register
+func encodeRegister(m *chip8.Memory, r register) string {
+	value := r.get(m)
+	if r.size == 1 {
+		return fmt.Sprintf("%02x", value)
+	}
+	return fmt.Sprintf("%04x", value)
+}
+
+// decodeRegister returns the value of a register from its hexadecimal value,
+// it fails if the register can not hold it
+func decodeRegister(m *chip8.Memory, r register, data string) (uint16, error) {
+	raw, err := hex.DecodeString(data)
+	if err != nil || len(raw) != r.size {
+		return 0, fmt.Errorf("gdbstub: bad value %q for %s", data, r.name)
+	}
+	value := uint16(raw[0])
+	if r.size == 2 {
+		value = value<<8 | uint16(raw[1])
+	}
+	if r.check != nil {
+		if err := r.check(m, value); err != nil {
+			return 0, err
+		}
+	}
+	return value, nil
+}
+
+// writeRegister sets a register from its hexadecimal value,
+// nothing is written if the value is bad
+func writeRegister(m *chip8.Memory, r register, data string) error {
+	value, err := decodeRegister(m, r, data)
+	if err != nil {
+		return err
+	}
+	r.set(m, value)
+	return nil
+}
+
+// readRegisters returns the g packet reply
+func readRegisters(m *chip8.Memory) string {
+	var b strings.Builder
+	for _, r := range registers {
+		b.WriteString(encodeRegister(m, r))
+	}
+	return b.String()
+}
+
+// writeRegisters sets every register from a G packet, the values are all
+// decoded first so nothing is written if one of them is bad
+func writeRegisters(m *chip8.Memory, data string) error {
+	values := make([]uint16, len(registers))
+	for n, r := range registers {
+		if len(data) < 2*r.size {
+			return fmt.Errorf("gdbstub: registers too short")
+		}
+		value, err := decodeRegister(m, r, data[:2*r.size])
+		if err != nil {
+			return err
+		}
+		values[n] = value
+		data = data[2*r.size:]
+	}
+	for n, r := range registers {
+		r.set(m, values[n])
+	}
+	return nil
+}
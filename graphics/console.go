@@ -0,0 +1,70 @@
+package graphics
+
+import (
+	"strings"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// ConsoleLines is the number of output lines shown above the prompt
+const ConsoleLines = 8
+
+// Console is the debugger command line at the bottom of the terminal,
+// it is opened with ':' and closed with Esc
+type Console struct {
+	// Active is set while the keys are typed in the console
+	Active bool
+
+	input  []rune
+	output []string
+}
+
+// HandleKey edits the command line with a key event,
+// it returns the line once Enter is pressed
+func (c *Console) HandleKey(ev termbox.Event) (string, bool) {
+	switch {
+	case ev.Key == termbox.KeyEsc:
+		c.Active = false
+		c.input = nil
+	case ev.Key == termbox.KeyEnter:
+		line := string(c.input)
+		c.input = nil
+		return line, true
+	case ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2:
+		if len(c.input) > 0 {
+			c.input = c.input[:len(c.input)-1]
+		}
+	case ev.Key == termbox.KeySpace:
+		c.input = append(c.input, ' ')
+	case ev.Ch != 0:
+		c.input = append(c.input, ev.Ch)
+	}
+	return "", false
+}
+
+// Print adds text to the output, only the last lines are kept
+func (c *Console) Print(text string) {
+	if text == "" {
+		return
+	}
+	c.output = append(c.output, strings.Split(text, "\n")...)
+	if len(c.output) > ConsoleLines {
+		c.output = c.output[len(c.output)-ConsoleLines:]
+	}
+}
+
+// Draw prints the output and the prompt at the bottom of the terminal
+func (c *Console) Draw() {
+	_, height := termbox.Size()
+	y := height - 1 - len(c.output)
+	for _, line := range c.output {
+		PrintString(0, y, termbox.ColorDefault, termbox.ColorDefault, line)
+		y++
+	}
+	if c.Active {
+		PrintString(0, y, termbox.ColorDefault, termbox.ColorDefault, ":"+string(c.input))
+		termbox.SetCursor(len(c.input)+1, y)
+	} else {
+		termbox.HideCursor()
+	}
+}
@@ -0,0 +1,138 @@
+package graphics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// KeyHold is how long a key stays held after its last terminal event.
+// Terminals only report key presses (and their auto repeat),
+// so the release of a key is guessed when its events stop.
+const KeyHold = 200 * time.Millisecond
+
+// Terminal is the termbox frontend of the chip8,
+// it is a chip8.PlaneDisplay and turns the terminal events into key changes
+type Terminal struct {
+	// SetKey is told of the presses and releases of the chip8 keys
+	SetKey func(key byte, pressed bool)
+
+	mu       sync.Mutex
+	releases [0x10]*time.Timer
+}
+
+// NewTerminal creates a Terminal telling setKey of the key changes
+func NewTerminal(setKey func(key byte, pressed bool)) *Terminal {
+	return &Terminal{SetKey: setKey}
+}
+
+// keyMap maps the keyboard to the chip8 hexadecimal keypad
+var keyMap = map[string]byte{
+	"3": 0x0, "4": 0x1, "5": 0x2, "6": 0x3,
+	"e": 0x4, "r": 0x5, "t": 0x6, "y": 0x7,
+	"d": 0x8, "f": 0x9, "g": 0xA, "h": 0xB,
+	"c": 0xC, "v": 0xD, "b": 0xE, "n": 0xF,
+}
+
+// KeyFromRune returns the chip8 key bound to the given character
+func KeyFromRune(ch rune) (byte, bool) {
+	str := string(ch)
+	if str >= "A" {
+		str = strings.ToLower(str)
+	}
+	k, ok := keyMap[str]
+	return k, ok
+}
+
+// Draw prints the chip8 screen on the terminal
+func (t *Terminal) Draw(screen [][]bool) {
+	PrintScreen(screen)
+}
+
+// DrawPlanes prints the two XO-CHIP planes on the terminal
+func (t *Terminal) DrawPlanes(plane1, plane2 [][]bool) {
+	PrintPlanes(plane1, plane2)
+}
+
+// HandleEvent turns a terminal event into key changes,
+// the key is pressed now and released KeyHold after its last event
+func (t *Terminal) HandleEvent(ev termbox.Event) {
+	if ev.Type != termbox.EventKey {
+		return
+	}
+	k, ok := KeyFromRune(ev.Ch)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.SetKey(k, true)
+	if t.releases[k] != nil {
+		t.releases[k].Stop()
+	}
+	t.releases[k] = time.AfterFunc(KeyHold, func() {
+		t.SetKey(k, false)
+	})
+}
+
+// PrintMemoryValues print chip8 state value
+func PrintMemoryValues(m *chip8.Memory) {
+	PrintPlanes(m.Screen, m.Screen2)
+	height := 0
+	width := 130
+	PrintString(width,
+		height,
+		termbox.ColorDefault,
+		termbox.ColorDefault,
+		"PC="+myLogger.Uint16ToString(m.PC))
+	height++
+	PrintString(width,
+		height,
+		termbox.ColorDefault,
+		termbox.ColorDefault,
+		"I="+myLogger.Uint16ToString(m.I))
+	height++
+	PrintString(width,
+		height,
+		termbox.ColorDefault,
+		termbox.ColorDefault,
+		"DT="+myLogger.ByteToString(m.DelayTimer)+" ST="+myLogger.ByteToString(m.SoundTimer))
+	height++
+	if m.SoundActive() {
+		PrintString(width,
+			height,
+			termbox.ColorDefault,
+			termbox.ColorDefault,
+			"SOUND")
+	}
+	height++
+	for i := 0; i < 0x10; i++ {
+		PrintString(width,
+			height,
+			termbox.ColorDefault,
+			termbox.ColorDefault,
+			"V["+strconv.Itoa(i)+"]="+myLogger.ByteToString(m.V[i]))
+		height++
+	}
+	height++
+	PrintDisassembly(m, width, height)
+}
+
+// PrintDisassembly print the instructions around the PC starting at the position x and y,
+// the current instruction is marked with an arrow
+func PrintDisassembly(m *chip8.Memory, x, y int) {
+	for _, in := range disasm.Around(m.Memory[:m.MemorySize()], m.PC, 4, 8, m.Quirks) {
+		prefix := "  "
+		if in.Address == m.PC {
+			prefix = "> "
+		}
+		PrintString(x, y, termbox.ColorDefault, termbox.ColorDefault, prefix+in.String())
+		y++
+	}
+}
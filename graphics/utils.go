@@ -14,16 +14,37 @@ func PrintString(x, y int, fg, bg termbox.Attribute, msg string) {
 	}
 }
 
-// PrintScreen Print a boolean array on the screen
+// PrintScreen Print a boolean array on the screen,
+// a low resolution pixel is two cells wide to look square
 func PrintScreen(screen [][]bool) {
-	point := 0
-	for x, superArr := range screen {
+	PrintPlanes(screen, nil)
+}
+
+// planeColors are the colors of a pixel lit on the first plane,
+// on the second plane and on both planes
+var planeColors = [4]termbox.Attribute{termbox.ColorDefault, termbox.ColorWhite, termbox.ColorRed, termbox.ColorYellow}
+
+// PrintPlanes print the two XO-CHIP planes on the screen,
+// plane2 can be nil for a monochrome screen
+func PrintPlanes(plane1, plane2 [][]bool) {
+	cells := 2
+	if len(plane1) > 64 {
+		cells = 1
+	}
+	for x, superArr := range plane1 {
 		for y, b := range superArr {
+			color := 0
 			if b {
-				termbox.SetCell(x+point, y, ' ', termbox.ColorDefault, termbox.ColorWhite)
-				termbox.SetCell(x+point+1, y, ' ', termbox.ColorDefault, termbox.ColorWhite)
+				color |= 1
+			}
+			if plane2 != nil && plane2[x][y] {
+				color |= 2
+			}
+			if color != 0 {
+				for c := 0; c < cells; c++ {
+					termbox.SetCell(x*cells+c, y, ' ', termbox.ColorDefault, planeColors[color])
+				}
 			}
 		}
-		point++
 	}
 }
@@ -0,0 +1,138 @@
+package headless
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// update rewrites the golden files with the screens of this run:
+// go test ./headless -run Golden -update
+var update = flag.Bool("update", false, "rewrite the golden files of the bundled roms")
+
+// romDir holds the bundled roms, goldenDir holds their golden files
+const (
+	romDir    = "../rom"
+	goldenDir = "testdata/golden"
+)
+
+// bundledRoms are the roms of romDir with a golden file, the directory
+// also holds the save states written while playing them
+var bundledRoms = []string{
+	"15PUZZLE", "BLINKY", "BLITZ", "BRIX", "CONNECT4", "GUESS", "HIDDEN", "IBM",
+	"INVADERS", "KALEID", "MAZE", "MERLIN", "MISSILE", "PONG", "PONG2", "PUZZLE",
+	"SYZYGY", "TANK", "TETRIS", "TICTAC", "UFO", "VBRIX", "VERS", "WIPEOFF",
+}
+
+// goldenSeed is the seed of every golden run
+const goldenSeed = 1
+
+// checkpoints are the frames where the screen is hashed
+var checkpoints = []int{60, 300, 600, 1200}
+
+// goldenScript presses each key in turn for 10 frames every 40 frames,
+// enough to get most games past their title screen and moving
+func goldenScript() Script {
+	keys := []byte{5, 4, 6, 8, 2, 1, 0xF, 0xC, 0xA, 3}
+	var script Script
+	for i := 0; i*40 < checkpoints[len(checkpoints)-1]; i++ {
+		key := keys[i%len(keys)]
+		script = append(script,
+			KeyEvent{Frame: i*40 + 20, Key: key, Pressed: true},
+			KeyEvent{Frame: i*40 + 30, Key: key})
+	}
+	return script
+}
+
+type GoldenTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GoldenTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// screenHash returns the SHA-256 of the ASCII art of the screen
+func screenHash(m *chip8.Memory) string {
+	var buf bytes.Buffer
+	WriteASCII(&buf, m)
+	return fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+}
+
+// goldenRun runs a rom and returns its golden file: a line per checkpoint
+// with the frame and the screen hash, and the error stopping the rom if any.
+// The screens are returned by frame to show them when they differ.
+func goldenRun(path string) (string, map[string]string) {
+	m := chip8.NewSeededMemory(chip8.QuirksVIP, goldenSeed)
+	if err := m.LoadRom(path); err != nil {
+		panic(err)
+	}
+	lines := []string{fmt.Sprintf("# %s, seed %d, vip quirks, frame and screen SHA-256", filepath.Base(path), goldenSeed)}
+	screens := make(map[string]string)
+	next := 0
+	err := Run(m, Options{
+		Frames: checkpoints[len(checkpoints)-1],
+		Script: goldenScript(),
+		OnFrame: func(frames int) {
+			if frames != checkpoints[next] {
+				return
+			}
+			next++
+			line := fmt.Sprintf("%d %s", frames, screenHash(m))
+			var buf bytes.Buffer
+			WriteASCII(&buf, m)
+			screens[line] = buf.String()
+			lines = append(lines, line)
+		},
+	})
+	if err != nil {
+		lines = append(lines, "error: "+err.Error())
+	}
+	return strings.Join(lines, "\n") + "\n", screens
+}
+
+func (suite *GoldenTestSuite) TestBundledRoms() {
+	if *update {
+		assert.Nil(suite.T(), os.MkdirAll(goldenDir, 0755))
+	}
+	for _, name := range bundledRoms {
+		name := name
+		suite.Run(name, func() {
+			// Act
+			got, screens := goldenRun(filepath.Join(romDir, name))
+
+			// Assert
+			goldenPath := filepath.Join(goldenDir, name+".golden")
+			if *update {
+				assert.Nil(suite.T(), ioutil.WriteFile(goldenPath, []byte(got), 0644))
+				return
+			}
+			want, err := ioutil.ReadFile(goldenPath)
+			if !assert.Nil(suite.T(), err, "Golden file missing, run go test with -update") {
+				return
+			}
+			if !assert.Equal(suite.T(), string(want), got, "Screens changed") {
+				for _, line := range strings.Split(got, "\n") {
+					if screen, ok := screens[line]; ok && !strings.Contains(string(want), line) {
+						suite.T().Logf("screen at frame %s:\n%s", strings.Fields(line)[0], screen)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGoldenTestSuite(t *testing.T) {
+	suite.Run(t, new(GoldenTestSuite))
+}
@@ -0,0 +1,143 @@
+// Package headless runs a chip8 without a terminal, its keys are pressed
+// by a script and its final screen and state are written to files
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/scheduler"
+)
+
+// Options selects how long a chip8 runs, Run stops at the first limit reached
+type Options struct {
+	// Cycles is the number of instructions to run, 0 means no limit
+	Cycles uint64
+	// Frames is the number of 60 Hz frames to run, 0 means no limit
+	Frames int
+	// IPS is the number of instructions per second, spread on the frames
+	// like in the terminal, scheduler.DefaultIPS if 0
+	IPS int
+	// VIPTiming runs the instructions that fit in a frame of the COSMAC VIP
+	// instead of IPS
+	VIPTiming bool
+	// Script presses and releases the keys, it can be nil
+	Script Script
+	// Iterate executes one instruction, it is the Iterate of the chip8 if nil
+	Iterate func() error
+	// OnFrame is called with the number of frames done at the end of each frame,
+	// it can be nil
+	OnFrame func(frames int)
+}
+
+// KeyEvent presses or releases a key at the start of a frame
+type KeyEvent struct {
+	Frame   int
+	Key     byte
+	Pressed bool
+}
+
+// Script is a list of key events sorted by frame
+type Script []KeyEvent
+
+// ParseScript reads a script made of lines like "30 press 5" or
+// "40 release 5", the key is a hexadecimal digit and # starts a comment
+func ParseScript(r io.Reader) (Script, error) {
+	var script Script
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected FRAME press|release KEY", line)
+		}
+		frame, err := strconv.Atoi(fields[0])
+		if err != nil || frame < 0 {
+			return nil, fmt.Errorf("line %d: invalid frame %s", line, fields[0])
+		}
+		if len(script) > 0 && frame < script[len(script)-1].Frame {
+			return nil, fmt.Errorf("line %d: frame %d before frame %d", line, frame, script[len(script)-1].Frame)
+		}
+		var pressed bool
+		switch strings.ToLower(fields[1]) {
+		case "press":
+			pressed = true
+		case "release":
+		default:
+			return nil, fmt.Errorf("line %d: unknown action %s", line, fields[1])
+		}
+		key, err := strconv.ParseUint(fields[2], 16, 4)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid key %s", line, fields[2])
+		}
+		script = append(script, KeyEvent{Frame: frame, Key: byte(key), Pressed: pressed})
+	}
+	return script, scanner.Err()
+}
+
+// Run runs m with a keypad driven by the script until a limit of the options
+// is reached or the program exits, the timers tick once a frame
+func Run(m *chip8.Memory, o Options) error {
+	if o.Cycles == 0 && o.Frames == 0 {
+		return fmt.Errorf("headless run needs a number of cycles or frames")
+	}
+	if o.IPS <= 0 {
+		o.IPS = scheduler.DefaultIPS
+	}
+	if o.Iterate == nil {
+		o.Iterate = m.Iterate
+	}
+	keypad := &chip8.Keypad{}
+	m.Input = keypad
+	script := o.Script
+	sched := scheduler.New(o.IPS)
+	sched.VIP = o.VIPTiming
+	var cycles uint64
+	var stop bool
+	var err error
+	step := func() bool {
+		if o.Cycles > 0 && cycles == o.Cycles {
+			stop = true
+			return false
+		}
+		if err = o.Iterate(); err != nil {
+			stop = true
+			return false
+		}
+		cycles++
+		return true
+	}
+	for frame := 0; o.Frames == 0 || frame < o.Frames; frame++ {
+		for len(script) > 0 && script[0].Frame <= frame {
+			if script[0].Pressed {
+				keypad.Press(script[0].Key)
+			} else {
+				keypad.Release(script[0].Key)
+			}
+			script = script[1:]
+		}
+		done := sched.RunFrame(m, step)
+		if stop {
+			if err == chip8.ErrHalted {
+				return nil
+			}
+			return err
+		}
+		sched.EndFrame(done)
+		m.TickTimers()
+		if o.OnFrame != nil {
+			o.OnFrame(frame + 1)
+		}
+	}
+	return nil
+}
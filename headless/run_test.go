@@ -0,0 +1,163 @@
+package headless
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/Oicho/GO-Chip8/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RunTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RunTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// createHeadlessMem creates a chip8 running the source program
+func createHeadlessMem(src string) *chip8.Memory {
+	rom, err := asm.Assemble("test.asm", []byte(src))
+	if err != nil {
+		panic(err)
+	}
+	m := chip8.NewSeededMemory(chip8.QuirksSCHIP, 1)
+	copy(m.Memory[0x200:], rom)
+	return m
+}
+
+// waitKey counts in V0 the frames the key 5 is held
+const waitKey = `
+        LD V1, 5
+loop:   SKNP V1
+        ADD V0, 1
+        JP loop
+`
+
+func (suite *RunTestSuite) TestCycles() {
+	// Adapt
+	m := createHeadlessMem(waitKey)
+
+	// Act
+	err := Run(m, Options{Cycles: 25})
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint64(25), m.Cycles)
+}
+
+func (suite *RunTestSuite) TestFrames() {
+	// Adapt
+	m := createHeadlessMem("LD V0, 60\nLD DT, V0\nloop: JP loop")
+
+	// Act
+	err := Run(m, Options{Frames: 20, IPS: scheduler.FromCyclesPerFrame(4)})
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint64(80), m.Cycles, "Frames of 4 instructions")
+	assert.Equal(suite.T(), byte(40), m.DelayTimer, "Timers tick once a frame")
+}
+
+func (suite *RunTestSuite) TestFrames_DefaultIPS() {
+	// Adapt
+	m := createHeadlessMem("loop: JP loop")
+
+	// Act
+	err := Run(m, Options{Frames: scheduler.FrameRate})
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint64(scheduler.DefaultIPS), m.Cycles, "A second at the speed of the terminal")
+}
+
+func (suite *RunTestSuite) TestFrames_VIPTiming() {
+	// Adapt
+	m := createHeadlessMem("loop: ADD V0, 1\nJP loop")
+
+	// Act
+	err := Run(m, Options{Frames: 2, VIPTiming: true})
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), uint64(72), m.Cycles, "Instructions that fit in two VIP frames")
+	assert.Equal(suite.T(), byte(36), m.V[0], "Additions of 50 cycles and jumps of 52")
+}
+
+func (suite *RunTestSuite) TestOnFrame() {
+	// Adapt
+	m := createHeadlessMem("loop: JP loop")
+	var frames []int
+
+	// Act
+	Run(m, Options{Frames: 3, OnFrame: func(n int) { frames = append(frames, n) }})
+
+	// Assert
+	assert.Equal(suite.T(), []int{1, 2, 3}, frames)
+}
+
+func (suite *RunTestSuite) TestNoLimit() {
+	// Act
+	err := Run(createHeadlessMem("loop: JP loop"), Options{})
+
+	// Assert
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *RunTestSuite) TestHalted() {
+	// Adapt
+	m := createHeadlessMem("EXIT")
+
+	// Act
+	err := Run(m, Options{Frames: 10})
+
+	// Assert
+	assert.Nil(suite.T(), err, "Exit ends the run")
+	assert.True(suite.T(), m.Halted)
+}
+
+func (suite *RunTestSuite) TestInvalid() {
+	// Act
+	err := Run(createHeadlessMem("DB 0xFF, 0xFF"), Options{Frames: 10})
+
+	// Assert
+	assert.NotNil(suite.T(), err)
+}
+
+func (suite *RunTestSuite) TestScript() {
+	// Adapt
+	m := createHeadlessMem(waitKey)
+	script, err := ParseScript(strings.NewReader("# hold 5\n2 press 5\n5 release 5\n"))
+
+	// Act
+	runErr := Run(m, Options{Frames: 10, IPS: scheduler.FromCyclesPerFrame(3), Script: script})
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), runErr)
+	assert.True(suite.T(), m.V[0] > 0, "Key seen while held")
+	assert.True(suite.T(), m.V[0] < 10, "Key released")
+}
+
+func (suite *RunTestSuite) TestParseScript_Errors() {
+	// Act
+	_, fields := ParseScript(strings.NewReader("2 press"))
+	_, action := ParseScript(strings.NewReader("2 hold 5"))
+	_, key := ParseScript(strings.NewReader("2 press G"))
+	_, order := ParseScript(strings.NewReader("5 press 1\n2 release 1"))
+
+	// Assert
+	assert.NotNil(suite.T(), fields)
+	assert.NotNil(suite.T(), action)
+	assert.NotNil(suite.T(), key)
+	assert.NotNil(suite.T(), order)
+}
+
+func TestRunTestSuite(t *testing.T) {
+	suite.Run(t, new(RunTestSuite))
+}
@@ -0,0 +1,121 @@
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// asciiPixels are the characters of a pixel lit on no plane,
+// on the first plane, on the second plane and on both planes
+var asciiPixels = [4]byte{'.', '#', '+', '@'}
+
+// palette are the colors of the pixels in the PNG, in the same order
+var palette = color.Palette{
+	color.Gray{0x00},
+	color.Gray{0xFF},
+	color.Gray{0x80},
+	color.Gray{0xC0},
+}
+
+// pixel returns the planes a pixel is lit on, bit 0 is Screen and bit 1 is Screen2
+func pixel(m *chip8.Memory, x, y int) int {
+	p := 0
+	if m.Screen[x][y] {
+		p |= 1
+	}
+	if m.Screen2 != nil && m.Screen2[x][y] {
+		p |= 2
+	}
+	return p
+}
+
+// size returns the width and the height of the screen
+func size(m *chip8.Memory) (int, int) {
+	return len(m.Screen), len(m.Screen[0])
+}
+
+// WriteASCII writes the screen as text, one line per row,
+// see asciiPixels for the characters
+func WriteASCII(w io.Writer, m *chip8.Memory) error {
+	width, height := size(m)
+	bw := bufio.NewWriter(w)
+	row := make([]byte, width+1)
+	row[width] = '\n'
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			row[x] = asciiPixels[pixel(m, x, y)]
+		}
+		bw.Write(row)
+	}
+	return bw.Flush()
+}
+
+// WritePBM writes the screen as a plain PBM image,
+// a pixel is black when it is lit on any plane
+func WritePBM(w io.Writer, m *chip8.Memory) error {
+	width, height := size(m)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P1\n%d %d\n", width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x > 0 {
+				bw.WriteByte(' ')
+			}
+			if pixel(m, x, y) != 0 {
+				bw.WriteByte('1')
+			} else {
+				bw.WriteByte('0')
+			}
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WritePNG writes the screen as a PNG image with one pixel per chip8 pixel,
+// see palette for the colors
+func WritePNG(w io.Writer, m *chip8.Memory) error {
+	width, height := size(m)
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetColorIndex(x, y, uint8(pixel(m, x, y)))
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// WriteScreenFile writes the screen to a file in the format of its extension,
+// .png, .pbm, or ASCII art for any other extension
+func WriteScreenFile(path string, m *chip8.Memory) error {
+	write := WriteASCII
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		write = WritePNG
+	case ".pbm":
+		write = WritePBM
+	}
+	return writeFile(path, func(w io.Writer) error { return write(w, m) })
+}
+
+// writeFile creates a file and writes it, the close error is reported
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,82 @@
+package headless
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScreenTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ScreenTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *ScreenTestSuite) TestASCII() {
+	// Adapt
+	m := createHeadlessMem("")
+	m.Screen[0][0] = true
+	m.Screen2[1][0] = true
+	m.Screen[2][0] = true
+	m.Screen2[2][0] = true
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteASCII(&buf, m)
+
+	// Assert
+	lines := strings.Split(buf.String(), "\n")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 33, len(lines), "32 rows and a final newline")
+	assert.Equal(suite.T(), "#+@"+strings.Repeat(".", 61), lines[0])
+	assert.Equal(suite.T(), strings.Repeat(".", 64), lines[1])
+}
+
+func (suite *ScreenTestSuite) TestPBM() {
+	// Adapt
+	m := createHeadlessMem("")
+	m.Screen[1][0] = true
+	var buf bytes.Buffer
+
+	// Act
+	err := WritePBM(&buf, m)
+
+	// Assert
+	lines := strings.Split(buf.String(), "\n")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "P1", lines[0])
+	assert.Equal(suite.T(), "64 32", lines[1])
+	assert.Equal(suite.T(), "0 1 0", lines[2][:5])
+}
+
+func (suite *ScreenTestSuite) TestPNG() {
+	// Adapt
+	m := createHeadlessMem("HIGH")
+	Run(m, Options{Cycles: 1})
+	m.Screen[127][63] = true
+	var buf bytes.Buffer
+
+	// Act
+	err := WritePNG(&buf, m)
+	img, decodeErr := png.Decode(&buf)
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), decodeErr)
+	assert.Equal(suite.T(), 128, img.Bounds().Dx(), "High resolution width")
+	r, _, _, _ := img.At(127, 63).RGBA()
+	assert.Equal(suite.T(), uint32(0xFFFF), r, "Lit pixel")
+	r, _, _, _ = img.At(0, 0).RGBA()
+	assert.Equal(suite.T(), uint32(0), r, "Dark pixel")
+}
+
+func TestScreenTestSuite(t *testing.T) {
+	suite.Run(t, new(ScreenTestSuite))
+}
@@ -0,0 +1,58 @@
+package headless
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// State is the JSON dump of a chip8, the memory is the addressable one
+// in hexadecimal and the stack holds the SP return addresses in use
+type State struct {
+	PC         uint16   `json:"pc"`
+	I          uint16   `json:"i"`
+	SP         uint16   `json:"sp"`
+	V          [16]byte `json:"v"`
+	Stack      []uint16 `json:"stack"`
+	DelayTimer byte     `json:"dt"`
+	SoundTimer byte     `json:"st"`
+	Cycles     uint64   `json:"cycles"`
+	HiRes      bool     `json:"hires"`
+	Halted     bool     `json:"halted"`
+	Planes     byte     `json:"planes"`
+	RPL        [16]byte `json:"rpl"`
+	Memory     string   `json:"memory"`
+}
+
+// NewState returns the state of m
+func NewState(m *chip8.Memory) State {
+	return State{
+		PC:         m.PC,
+		I:          m.I,
+		SP:         m.SP,
+		V:          m.V,
+		Stack:      append([]uint16{}, m.CallStack[:m.SP]...),
+		DelayTimer: m.DelayTimer,
+		SoundTimer: m.SoundTimer,
+		Cycles:     m.Cycles,
+		HiRes:      m.HiRes,
+		Halted:     m.Halted,
+		Planes:     m.Planes,
+		RPL:        m.RPL,
+		Memory:     hex.EncodeToString(m.Memory[:m.MemorySize()]),
+	}
+}
+
+// WriteState writes the state of m as indented JSON
+func WriteState(w io.Writer, m *chip8.Memory) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(NewState(m))
+}
+
+// WriteStateFile writes the state of m to a JSON file
+func WriteStateFile(path string, m *chip8.Memory) error {
+	return writeFile(path, func(w io.Writer) error { return WriteState(w, m) })
+}
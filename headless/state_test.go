@@ -0,0 +1,47 @@
+package headless
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *StateTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *StateTestSuite) TestWriteState() {
+	// Adapt
+	m := createHeadlessMem("LD V3, 0x42\nCALL sub\nsub: LD I, 0x300\nloop: JP loop")
+	Run(m, Options{Cycles: 4})
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteState(&buf, m)
+	var s State
+	decodeErr := json.Unmarshal(buf.Bytes(), &s)
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), decodeErr)
+	assert.Equal(suite.T(), uint16(0x206), s.PC)
+	assert.Equal(suite.T(), uint16(0x300), s.I)
+	assert.Equal(suite.T(), byte(0x42), s.V[3])
+	assert.Equal(suite.T(), []uint16{0x204}, s.Stack, "Return addresses in use")
+	assert.Equal(suite.T(), uint64(4), s.Cycles)
+	memory, _ := hex.DecodeString(s.Memory)
+	assert.Equal(suite.T(), m.Memory[:m.MemorySize()], memory)
+}
+
+func TestStateTestSuite(t *testing.T) {
+	suite.Run(t, new(StateTestSuite))
+}
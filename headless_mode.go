@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/headless"
+	"github.com/Oicho/GO-Chip8/trace"
+)
+
+// runHeadless runs the rom without the terminal as set by the -headless flags,
+// the screen and the state are written even when the program fails.
+// The ips are spread on the frames like in the terminal, 0 keeps the default.
+func runHeadless(romPath string, quirks chip8.Quirks, seed int64, ips int, recorder *trace.Recorder) error {
+	if *cyclesFlag == 0 && *framesFlag == 0 {
+		return errors.New("-headless needs -cycles or -frames")
+	}
+	mem := chip8.NewSeededMemory(quirks, seed)
+	if err := mem.LoadRom(romPath); err != nil {
+		return err
+	}
+	options := headless.Options{Cycles: *cyclesFlag, Frames: *framesFlag, IPS: ips, VIPTiming: *vipTimingFlag}
+	if *keysFlag != "" {
+		file, err := os.Open(*keysFlag)
+		if err != nil {
+			return err
+		}
+		options.Script, err = headless.ParseScript(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if recorder != nil {
+		options.Iterate = func() error { return recorder.Step(mem, mem.Iterate) }
+	}
+	runErr := headless.Run(mem, options)
+	if *screenFlag != "" {
+		if err := headless.WriteScreenFile(*screenFlag, mem); err != nil {
+			return err
+		}
+	} else if err := headless.WriteASCII(os.Stdout, mem); err != nil {
+		return err
+	}
+	if *stateFlag != "" {
+		if err := headless.WriteStateFile(*stateFlag, mem); err != nil {
+			return err
+		}
+	}
+	return runErr
+}
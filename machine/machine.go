@@ -0,0 +1,374 @@
+// Package machine runs a chip8 in its own goroutine. Frontends drive it
+// with commands and are told of its frames, sound and stops with events,
+// they never touch the chip8 the goroutine owns.
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/debugger"
+	"github.com/Oicho/GO-Chip8/scheduler"
+	"github.com/Oicho/GO-Chip8/trace"
+)
+
+// ErrStopped is returned by the commands sent to a stopped machine
+var ErrStopped = errors.New("machine stopped")
+
+// romAddress is where the roms are loaded
+const romAddress = 0x200
+
+// Config sets up a machine and the chip8s it creates
+type Config struct {
+	Quirks chip8.Quirks
+	Seed   int64
+	// IPS is the number of instructions per second, scheduler.DefaultIPS if 0
+	IPS int
+	// VIPTiming paces the program with the COSMAC VIP cycle costs instead of IPS
+	VIPTiming bool
+	// Paused starts the machine paused
+	Paused bool
+	// Rewind and Trace are given to the debugger, they can be nil
+	Rewind *chip8.Rewind
+	Trace  *trace.Recorder
+	// Service is called in the machine goroutine on every frame, even paused,
+	// the chip8 is published when it returns true. It can be nil.
+	Service func() bool
+}
+
+// EventKind tells what happened to the machine
+type EventKind int
+
+// The events of a machine
+const (
+	// FrameReady comes with a Snapshot after each frame and each command
+	FrameReady EventKind = iota
+	// SoundOn and SoundOff are sent when the buzzer starts and stops
+	SoundOn
+	SoundOff
+	// Paused is sent when the debugger stops the program, Message tells why
+	Paused
+	// Halted is sent when the program exits or fails, Err is chip8.ErrHalted
+	// or the error of the instruction
+	Halted
+)
+
+var eventNames = [...]string{"frame ready", "sound on", "sound off", "paused", "halted"}
+
+// String returns the name of the event kind
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventNames) {
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+	return eventNames[k]
+}
+
+// Event is sent by a machine on its Events channel
+type Event struct {
+	Kind EventKind
+	// Snapshot is a copy of the chip8, Running tells if the program runs
+	// and Status is the measured speed, they are set for FrameReady
+	Snapshot *chip8.Memory
+	Running  bool
+	Status   string
+
+	Message string
+	Err     error
+}
+
+// Machine owns a chip8 and its debugger and runs them in a goroutine.
+// Its methods send commands to the goroutine and wait for their result.
+type Machine struct {
+	commands chan func()
+	events   chan Event
+	done     chan struct{}
+
+	// the fields below belong to the goroutine
+	config  Config
+	rom     []byte
+	mem     *chip8.Memory
+	keypad  *chip8.Keypad
+	dbg     *debugger.Debugger
+	sched   *scheduler.Scheduler
+	sound   bool
+	pending []Event
+	// frameDone is the number of instructions of the last frame run
+	frameDone int
+}
+
+// Start creates a machine running an empty rom until LoadRom,
+// it stops when ctx is cancelled
+func Start(ctx context.Context, c Config) *Machine {
+	if c.IPS <= 0 {
+		c.IPS = scheduler.DefaultIPS
+	}
+	m := &Machine{
+		commands: make(chan func()),
+		events:   make(chan Event),
+		done:     make(chan struct{}),
+		config:   c,
+		keypad:   &chip8.Keypad{},
+		sched:    scheduler.New(c.IPS),
+	}
+	m.sched.VIP = c.VIPTiming
+	m.mem = m.newMemory()
+	m.dbg = debugger.New(m.mem)
+	m.dbg.Rewind = c.Rewind
+	m.dbg.Trace = c.Trace
+	m.dbg.Running = !c.Paused
+	go m.run(ctx)
+	return m
+}
+
+// Events returns the channel of the events, it is closed once the machine stopped.
+// Pending events of the same kind are merged, only the latest FrameReady is kept.
+func (m *Machine) Events() <-chan Event {
+	return m.events
+}
+
+// Done returns a channel closed once the machine stopped,
+// nothing runs in its goroutine anymore
+func (m *Machine) Done() <-chan struct{} {
+	return m.done
+}
+
+// Pause stops the program
+func (m *Machine) Pause() error {
+	return m.do(func() { m.dbg.Pause() })
+}
+
+// Resume runs the program
+func (m *Machine) Resume() error {
+	return m.do(func() {
+		m.dbg.Resume()
+		m.sched.Reset()
+	})
+}
+
+// Step pauses the program and executes one instruction,
+// it returns the watchpoints hit by the instruction
+func (m *Machine) Step() (out string, err error) {
+	if doErr := m.do(func() {
+		var hits []string
+		hits, err = m.dbg.Step()
+		out = strings.Join(hits, "\n")
+	}); doErr != nil {
+		return "", doErr
+	}
+	return out, err
+}
+
+// AdvanceFrame runs the instructions of one frame then pauses the program
+func (m *Machine) AdvanceFrame() error {
+	return m.do(func() {
+		m.dbg.Resume()
+		m.runFrame()
+		m.dbg.Pause()
+	})
+}
+
+// RewindFrame pauses the program and brings it back about a frame, to the
+// last rewind snapshot taken at least a frame of instructions before.
+// Called on each repeat of a key, it rewinds at the pace of the repeats.
+func (m *Machine) RewindFrame() error {
+	err := ErrStopped
+	if doErr := m.do(func() {
+		m.dbg.Pause()
+		if m.dbg.Rewind == nil {
+			err = errors.New("rewind is disabled")
+			return
+		}
+		frame := m.frameDone
+		if frame <= 0 {
+			frame = m.sched.FrameCycles()
+		}
+		err = m.dbg.Rewind.BackBy(m.mem, uint64(frame))
+	}); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+// Reset restarts the rom on a new chip8, the breakpoints are kept
+func (m *Machine) Reset() error {
+	return m.do(m.reset)
+}
+
+// LoadRom replaces the rom and restarts it
+func (m *Machine) LoadRom(rom []byte) error {
+	err := ErrStopped
+	if doErr := m.do(func() {
+		if size := m.mem.MemorySize() - romAddress; len(rom) > size {
+			err = fmt.Errorf("rom of %d bytes, the memory holds %d", len(rom), size)
+			return
+		}
+		m.rom = append([]byte(nil), rom...)
+		m.reset()
+		err = nil
+	}); doErr != nil {
+		return doErr
+	}
+	return err
+}
+
+// SetKey presses or releases a key of the keypad
+func (m *Machine) SetKey(key byte, pressed bool) error {
+	return m.do(func() {
+		if pressed {
+			m.keypad.Press(key)
+		} else {
+			m.keypad.Release(key)
+		}
+	})
+}
+
+// Snapshot returns a copy of the chip8
+func (m *Machine) Snapshot() (snapshot *chip8.Memory, err error) {
+	err = m.do(func() { snapshot = m.mem.Clone() })
+	return snapshot, err
+}
+
+// ToggleSpeed switches to a speed of the scheduler or back to normal
+func (m *Machine) ToggleSpeed(speed scheduler.Speed) error {
+	return m.do(func() { m.sched.Toggle(speed) })
+}
+
+// Exec runs a debugger command
+func (m *Machine) Exec(line string) (out string, err error) {
+	if doErr := m.do(func() { out, err = m.dbg.Exec(line) }); doErr != nil {
+		return "", doErr
+	}
+	return out, err
+}
+
+// Do runs f with the debugger in the machine goroutine, for the work not
+// covered by the other commands. f must not keep the debugger or its chip8.
+func (m *Machine) Do(f func(d *debugger.Debugger)) error {
+	return m.do(func() { f(m.dbg) })
+}
+
+// do runs f in the machine goroutine and waits for it to return
+func (m *Machine) do(f func()) error {
+	returned := make(chan struct{})
+	select {
+	case m.commands <- func() { f(); close(returned) }:
+	case <-m.done:
+		return ErrStopped
+	}
+	<-returned
+	return nil
+}
+
+// run is the machine goroutine, it runs the commands, the frames and
+// sends the events until ctx is cancelled
+func (m *Machine) run(ctx context.Context) {
+	defer close(m.done)
+	defer close(m.events)
+	tick := time.After(0)
+	for {
+		var out chan<- Event
+		var next Event
+		if len(m.pending) > 0 {
+			out, next = m.events, m.pending[0]
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case f := <-m.commands:
+			f()
+			m.publishFrame()
+		case out <- next:
+			m.pending = m.pending[1:]
+		case <-tick:
+			tick = time.After(m.frame())
+		}
+	}
+}
+
+// frame runs a frame when the program runs and returns the time until the next one
+func (m *Machine) frame() time.Duration {
+	if m.config.Service != nil && m.config.Service() {
+		m.publishFrame()
+	}
+	if !m.dbg.Running {
+		m.sched.Reset()
+		return scheduler.FrameDuration
+	}
+	m.runFrame()
+	return m.sched.Delay()
+}
+
+// runFrame runs the instructions of a frame, or less if the debugger stops,
+// then ticks the timers
+func (m *Machine) runFrame() {
+	done := m.sched.RunFrame(m.mem, func() bool {
+		if !m.dbg.Running {
+			return false
+		}
+		out, err := m.dbg.Iterate()
+		if err != nil {
+			m.publish(Event{Kind: Halted, Err: err})
+		} else if out != "" {
+			m.publish(Event{Kind: Paused, Message: out})
+		}
+		return true
+	})
+	if done > 0 && m.dbg.Running {
+		m.frameDone = done
+	}
+	m.mem.TickTimers()
+	m.sched.EndFrame(done)
+	if sound := m.mem.SoundActive(); sound != m.sound {
+		m.sound = sound
+		kind := SoundOff
+		if sound {
+			kind = SoundOn
+		}
+		m.publish(Event{Kind: kind})
+	}
+	m.publishFrame()
+}
+
+// newMemory creates a chip8 with the rom loaded
+func (m *Machine) newMemory() *chip8.Memory {
+	mem := chip8.NewSeededMemory(m.config.Quirks, m.config.Seed)
+	copy(mem.Memory[romAddress:], m.rom)
+	mem.Input = m.keypad
+	return mem
+}
+
+// reset restarts the rom on a new chip8
+func (m *Machine) reset() {
+	m.mem = m.newMemory()
+	m.dbg.Attach(m.mem)
+	if m.dbg.Rewind != nil {
+		m.dbg.Rewind.Clear()
+	}
+	m.sched.Reset()
+}
+
+// publishFrame queues a FrameReady event
+func (m *Machine) publishFrame() {
+	m.publish(Event{Kind: FrameReady, Snapshot: m.mem.Clone(), Running: m.dbg.Running, Status: m.sched.String()})
+}
+
+// publish queues an event for the Events channel,
+// a pending FrameReady or sound event is replaced by the new one
+func (m *Machine) publish(e Event) {
+	for i, p := range m.pending {
+		if (p.Kind == FrameReady && e.Kind == FrameReady) || (isSound(p.Kind) && isSound(e.Kind)) {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			break
+		}
+	}
+	m.pending = append(m.pending, e)
+}
+
+// isSound tells if the kind is SoundOn or SoundOff
+func isSound(k EventKind) bool {
+	return k == SoundOn || k == SoundOff
+}
@@ -0,0 +1,276 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// eventTimeout is how long a test waits for an event
+const eventTimeout = 2 * time.Second
+
+type MachineTestSuite struct {
+	suite.Suite
+	cancel context.CancelFunc
+}
+
+func (suite *MachineTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+func (suite *MachineTestSuite) TearDownTest() {
+	if suite.cancel != nil {
+		suite.cancel()
+	}
+}
+
+// startMachine starts a machine running the source program
+func (suite *MachineTestSuite) startMachine(src string, paused bool) *Machine {
+	return suite.startConfig(src, Config{Quirks: chip8.QuirksSCHIP, Seed: 1, Paused: paused})
+}
+
+// startConfig starts a machine of the config running the source program
+func (suite *MachineTestSuite) startConfig(src string, c Config) *Machine {
+	rom, err := asm.Assemble("test.asm", []byte(src))
+	if err != nil {
+		panic(err)
+	}
+	var ctx context.Context
+	ctx, suite.cancel = context.WithCancel(context.Background())
+	m := Start(ctx, c)
+	if err := m.LoadRom(rom); err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// waitFor returns the first event of the kind for which match holds, match can be nil
+func waitFor(m *Machine, kind EventKind, match func(e Event) bool) (Event, bool) {
+	timeout := time.After(eventTimeout)
+	for {
+		select {
+		case e, ok := <-m.Events():
+			if !ok {
+				return Event{}, false
+			}
+			if e.Kind == kind && (match == nil || match(e)) {
+				return e, true
+			}
+		case <-timeout:
+			return Event{}, false
+		}
+	}
+}
+
+func (suite *MachineTestSuite) TestRun() {
+	// Adapt
+	m := suite.startMachine("LD V0, 5\nloop: JP loop", false)
+
+	// Act
+	e, ok := waitFor(m, FrameReady, func(e Event) bool { return e.Snapshot.V[0] == 5 })
+
+	// Assert
+	assert.True(suite.T(), ok, "Frame after the first instruction")
+	assert.True(suite.T(), e.Running, "Running")
+	assert.Contains(suite.T(), e.Status, "/700 IPS", "Default speed")
+}
+
+func (suite *MachineTestSuite) TestPauseStep() {
+	// Adapt
+	m := suite.startMachine("LD V0, 5\nLD V1, 6\nloop: JP loop", true)
+
+	// Act
+	out, err := m.Step()
+	snapshot, snapErr := m.Snapshot()
+
+	// Assert
+	assert.Nil(suite.T(), err, "Step")
+	assert.Nil(suite.T(), snapErr, "Snapshot")
+	assert.Equal(suite.T(), "", out, "No watchpoint")
+	assert.Equal(suite.T(), uint16(0x202), snapshot.PC, "One instruction")
+	assert.Equal(suite.T(), byte(0), snapshot.V[1], "Second instruction not run")
+}
+
+func (suite *MachineTestSuite) TestAdvanceFrame() {
+	// Adapt
+	m := suite.startMachine("loop: ADD V0, 1\nJP loop", true)
+
+	// Act
+	err := m.AdvanceFrame()
+	snapshot, _ := m.Snapshot()
+
+	// Assert
+	assert.Nil(suite.T(), err, "Advance")
+	assert.Equal(suite.T(), byte(6), snapshot.V[0], "11 instructions, 6 additions")
+	e, _ := waitFor(m, FrameReady, nil)
+	assert.False(suite.T(), e.Running, "Paused after the frame")
+}
+
+func (suite *MachineTestSuite) TestAdvanceFrame_VIPTiming() {
+	// Adapt
+	m := suite.startConfig("loop: ADD V0, 1\nJP loop", Config{Quirks: chip8.QuirksVIP, Paused: true, VIPTiming: true})
+
+	// Act
+	err := m.AdvanceFrame()
+	snapshot, _ := m.Snapshot()
+
+	// Assert
+	assert.Nil(suite.T(), err, "Advance")
+	assert.Equal(suite.T(), byte(18), snapshot.V[0], "Additions of 50 cycles and jumps of 52 in a frame")
+	e, _ := waitFor(m, FrameReady, nil)
+	assert.Contains(suite.T(), e.Status, "/VIP IPS", "VIP timing")
+}
+
+func (suite *MachineTestSuite) TestRewindFrame() {
+	// Adapt
+	m := suite.startConfig("loop: ADD V0, 1\nJP loop", Config{Quirks: chip8.QuirksSCHIP, Paused: true, Rewind: chip8.NewRewind(1<<20, 1)})
+	m.AdvanceFrame()
+	m.AdvanceFrame()
+
+	// Act
+	err := m.RewindFrame()
+	snapshot, _ := m.Snapshot()
+
+	// Assert
+	assert.Nil(suite.T(), err, "Rewound")
+	assert.Equal(suite.T(), uint64(11), snapshot.Cycles, "Back a frame of 12 instructions")
+}
+
+func (suite *MachineTestSuite) TestRewindFrame_Pauses() {
+	// Adapt
+	m := suite.startConfig("loop: JP loop", Config{Quirks: chip8.QuirksSCHIP, Rewind: chip8.NewRewind(1<<20, 1)})
+	waitFor(m, FrameReady, func(e Event) bool { return e.Snapshot.Cycles > 0 })
+
+	// Act
+	m.RewindFrame()
+
+	// Assert
+	e, _ := waitFor(m, FrameReady, nil)
+	assert.False(suite.T(), e.Running, "Paused while rewinding")
+}
+
+func (suite *MachineTestSuite) TestRewindFrame_Disabled() {
+	// Adapt
+	m := suite.startMachine("loop: JP loop", true)
+
+	// Act
+	err := m.RewindFrame()
+
+	// Assert
+	assert.NotNil(suite.T(), err, "No rewind buffer")
+}
+
+func (suite *MachineTestSuite) TestSetKey() {
+	// Adapt
+	m := suite.startMachine("LD V0, K\nloop: JP loop", false)
+
+	// Act
+	m.SetKey(7, true)
+	for i := 0; i < 3; i++ {
+		// the first frame is the one of the command
+		waitFor(m, FrameReady, nil)
+	}
+	m.SetKey(7, false)
+	e, ok := waitFor(m, FrameReady, func(e Event) bool { return e.Snapshot.PC == 0x202 })
+
+	// Assert
+	assert.True(suite.T(), ok, "Key released")
+	assert.Equal(suite.T(), byte(7), e.Snapshot.V[0], "Key stored")
+}
+
+func (suite *MachineTestSuite) TestSound() {
+	// Adapt
+	m := suite.startMachine("LD V0, 3\nLD ST, V0\nloop: JP loop", false)
+
+	// Act
+	_, on := waitFor(m, SoundOn, nil)
+	_, off := waitFor(m, SoundOff, nil)
+
+	// Assert
+	assert.True(suite.T(), on, "Buzzer started")
+	assert.True(suite.T(), off, "Buzzer stopped")
+}
+
+func (suite *MachineTestSuite) TestHalted() {
+	// Adapt
+	m := suite.startMachine("EXIT", false)
+
+	// Act
+	e, ok := waitFor(m, Halted, nil)
+	frame, _ := waitFor(m, FrameReady, nil)
+
+	// Assert
+	assert.True(suite.T(), ok, "Halted")
+	assert.Equal(suite.T(), chip8.ErrHalted, e.Err, "Program exited")
+	assert.False(suite.T(), frame.Running, "Stopped")
+}
+
+func (suite *MachineTestSuite) TestReset() {
+	// Adapt
+	m := suite.startMachine("LD V0, 5\nloop: JP loop", true)
+	m.Step()
+
+	// Act
+	err := m.Reset()
+	snapshot, _ := m.Snapshot()
+
+	// Assert
+	assert.Nil(suite.T(), err, "Reset")
+	assert.Equal(suite.T(), uint16(0x200), snapshot.PC, "Restarted")
+	assert.Equal(suite.T(), byte(0x60), snapshot.Memory[0x200], "Rom loaded again")
+}
+
+func (suite *MachineTestSuite) TestLoadRom_TooLarge() {
+	// Adapt
+	m := suite.startMachine("EXIT", true)
+
+	// Act
+	err := m.LoadRom(make([]byte, 0x1000))
+
+	// Assert
+	assert.NotNil(suite.T(), err, "Rom past the memory")
+}
+
+func (suite *MachineTestSuite) TestCancel() {
+	// Adapt
+	m := suite.startMachine("loop: JP loop", false)
+
+	// Act
+	suite.cancel()
+	<-m.Done()
+	_, err := m.Snapshot()
+	_, open := waitFor(m, FrameReady, nil)
+
+	// Assert
+	assert.Equal(suite.T(), ErrStopped, err, "Command after the stop")
+	assert.False(suite.T(), open, "Events closed")
+}
+
+func (suite *MachineTestSuite) TestPublish_Merge() {
+	// Adapt
+	m := &Machine{}
+
+	// Act
+	m.publish(Event{Kind: FrameReady, Status: "1"})
+	m.publish(Event{Kind: SoundOn})
+	m.publish(Event{Kind: Paused, Message: "break"})
+	m.publish(Event{Kind: SoundOff})
+	m.publish(Event{Kind: FrameReady, Status: "2"})
+
+	// Assert
+	assert.Equal(suite.T(), []Event{
+		{Kind: Paused, Message: "break"},
+		{Kind: SoundOff},
+		{Kind: FrameReady, Status: "2"},
+	}, m.pending, "Latest frame and sound")
+}
+
+func TestMachineTestSuite(t *testing.T) {
+	suite.Run(t, new(MachineTestSuite))
+}
@@ -2,36 +2,246 @@ package main
 
 import (
 	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/debugger"
+	"github.com/Oicho/GO-Chip8/gdbstub"
+	"github.com/Oicho/GO-Chip8/graphics"
+	"github.com/Oicho/GO-Chip8/machine"
 	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/Oicho/GO-Chip8/scheduler"
+	"github.com/Oicho/GO-Chip8/trace"
 	termbox "github.com/nsf/termbox-go"
 
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
-	"time"
 )
 
+var (
+	pauseFlag  = flag.Bool("pause", false, "start the emulator paused")
+	quirksFlag = flag.String("quirks", "vip", "compatibility profile: "+strings.Join(chip8.QuirksNames(), ", "))
+	spriteFlag = flag.String("sprites", "", "override what to do with sprites past the screen edge: clip or wrap")
+	stackFlag  = flag.Int("stack", 0, "override the call stack depth of the profile")
+	seedFlag   = flag.Int64("seed", 0, "seed of the random number generator, based on the time if not set")
+
+	ipsFlag            = flag.Int("ips", 0, "instructions run per second, "+strconv.Itoa(scheduler.DefaultIPS)+" if neither this nor -cycles-per-frame is set")
+	cyclesPerFrameFlag = flag.Int("cycles-per-frame", 0, "instructions run per 60 Hz frame, instead of -ips")
+	vipTimingFlag      = flag.Bool("vip-timing", false, "charge the instructions about their COSMAC VIP cycles, instead of -ips")
+
+	rewindBudgetFlag   = flag.Int("rewind-budget", 16, "memory kept for rewinding, in MiB")
+	rewindIntervalFlag = flag.Uint64("rewind-interval", 16, "instructions between two rewind snapshots")
+	gdbFlag            = flag.String("gdb", "", "serve the GDB remote protocol on this address, like localhost:1234")
+	traceFlag          = flag.String("trace", "", "record the executed instructions in this file, read it with the trace command")
+	traceSizeFlag      = flag.Int64("trace-size", 64, "maximum size of the trace, in MiB")
+
+	headlessFlag = flag.Bool("headless", false, "run without the terminal, for -cycles or -frames, then write the screen")
+	cyclesFlag   = flag.Uint64("cycles", 0, "instructions run by -headless")
+	framesFlag   = flag.Int("frames", 0, "60 Hz frames run by -headless")
+	keysFlag     = flag.String("keys", "", "script of the keys pressed by -headless, with lines like: 30 press 5")
+	screenFlag   = flag.String("screen", "", "file of the final screen of -headless, .png, .pbm or ASCII art, printed if not set")
+	stateFlag    = flag.String("state", "", "JSON file of the final state of -headless")
+)
+
+// openTrace creates the trace file of the -trace flag, the recorder is nil
+// without the flag, done writes the end of the trace and closes the file
+func openTrace(path string, maxSize int64) (recorder *trace.Recorder, done func(), err error) {
+	if path == "" {
+		return nil, func() {}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorder = trace.NewRecorder(file, maxSize)
+	done = func() {
+		if err := recorder.Flush(); err != nil {
+			myLogger.ErrorPrint("Trace not written: " + err.Error())
+		}
+		file.Close()
+		if recorder.Dropped > 0 {
+			myLogger.InfoPrint("Trace full, " + strconv.FormatUint(recorder.Dropped, 10) + " instructions not recorded")
+		}
+	}
+	return recorder, done, nil
+}
+
+// saveKeys and loadKeys bind the function keys to the save slots,
+// F1 to F4 save the state and F5 to F8 restore it
+var (
+	saveKeys = map[termbox.Key]int{termbox.KeyF1: 1, termbox.KeyF2: 2, termbox.KeyF3: 3, termbox.KeyF4: 4}
+	loadKeys = map[termbox.Key]int{termbox.KeyF5: 1, termbox.KeyF6: 2, termbox.KeyF7: 3, termbox.KeyF8: 4}
+)
+
+// turboKey, slowKey and frameKey toggle the turbo and the slow motion
+// and run a single frame, the emulator is paused after a frame advance
+const (
+	turboKey = termbox.KeyTab
+	slowKey  = "m"
+	frameKey = "."
+)
+
+// statusX and statusY are where the measured speed is printed
+const (
+	statusX = 130
+	statusY = 35
+)
+
+// instructionsPerSecond returns the speed set by -ips or -cycles-per-frame, 0 if none is set
+func instructionsPerSecond() (int, error) {
+	switch {
+	case *ipsFlag < 0 || *cyclesPerFrameFlag < 0:
+		return 0, errors.New("-ips and -cycles-per-frame must be positive")
+	case *ipsFlag > 0 && *cyclesPerFrameFlag > 0:
+		return 0, errors.New("-ips and -cycles-per-frame can not be used together")
+	case *vipTimingFlag && (*ipsFlag > 0 || *cyclesPerFrameFlag > 0):
+		return 0, errors.New("-vip-timing can not be used with -ips or -cycles-per-frame")
+	case *cyclesPerFrameFlag > 0:
+		return scheduler.FromCyclesPerFrame(*cyclesPerFrameFlag), nil
+	}
+	return *ipsFlag, nil
+}
+
+// statePath returns the file of a save slot, next to the rom
+func statePath(romPath string, slot int) string {
+	return romPath + ".state" + strconv.Itoa(slot)
+}
+
+// commands are the tools run instead of the emulator
+// when their name is the first argument
+var commands = map[string]func(args []string) error{
+	"asm":    asmCommand,
+	"disasm": disasmCommand,
+	"trace":  traceCommand,
+}
+
 func main() {
-	if len(os.Args) == 1 {
-		fmt.Println("Usage: program filepath")
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: program [options] filepath")
+		fmt.Fprintln(os.Stderr, "       program [options] pause filepath")
+		fmt.Fprintln(os.Stderr, "       program asm [options] filepath")
+		fmt.Fprintln(os.Stderr, "       program disasm [options] filepath")
+		fmt.Fprintln(os.Stderr, "       program trace [options] filepath")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	var romPath, paused = flag.Arg(0), *pauseFlag
+	switch flag.NArg() {
+	case 1:
+	case 2:
+		// the first versions started paused when given a word before the rom
+		romPath, paused = flag.Arg(1), true
+	default:
+		flag.Usage()
+		return
+	}
+	quirks, ok := chip8.QuirksByName(*quirksFlag)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown quirks profile: "+*quirksFlag)
+		return
+	}
+	var seed = chip8.TimeSeed()
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seed = *seedFlag
+		}
+	})
+	if *stackFlag > 0 {
+		quirks.StackDepth = *stackFlag
+	}
+	if *spriteFlag != "" {
+		quirks.SpriteEdge, ok = chip8.ParseSpriteEdge(*spriteFlag)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "unknown sprite mode: "+*spriteFlag)
+			return
+		}
+	}
+	ips, err := instructionsPerSecond()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		return
 	}
 	myLogger.Init(true)
-	var mem = chip8.Memory{}
-	mem.Init()
-
-	err := termbox.Init()
-	var romPath string
-	var pause bool
+	myLogger.InfoPrint("Random seed " + strconv.FormatInt(seed, 10))
+	recorder, closeTrace, err := openTrace(*traceFlag, *traceSizeFlag<<20)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	defer closeTrace()
+	if *headlessFlag {
+		if err := runHeadless(romPath, quirks, seed, ips, recorder); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			closeTrace()
+			os.Exit(1)
+		}
+		return
+	}
+	rom, err := ioutil.ReadFile(romPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	var rewind = chip8.NewRewind(*rewindBudgetFlag<<20, *rewindIntervalFlag)
+	var server *gdbstub.Server
+	ctx, cancel := context.WithCancel(context.Background())
+	var mach = machine.Start(ctx, machine.Config{
+		Quirks:    quirks,
+		Seed:      seed,
+		IPS:       ips,
+		VIPTiming: *vipTimingFlag,
+		Paused:    paused,
+		Rewind:    rewind,
+		Trace:     recorder,
+		Service:   func() bool { return server != nil && server.Service() },
+	})
+	// the trace and the gdb server are closed once the machine stopped using them
+	defer func() {
+		cancel()
+		<-mach.Done()
+		if server != nil {
+			server.Close()
+		}
+	}()
+	if err := mach.LoadRom(rom); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if *gdbFlag != "" {
+		mach.Do(func(d *debugger.Debugger) {
+			server, err = gdbstub.Listen(*gdbFlag, d)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		myLogger.InfoPrint("GDB server listening on " + server.Addr().String())
+	}
+	var terminal = graphics.NewTerminal(func(key byte, pressed bool) {
+		mach.SetKey(key, pressed)
+	})
+	var console = &graphics.Console{}
+	snapshot, err := mach.Snapshot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	var running = !paused
+	var status string
 
-	if (len(os.Args) == 3) {
-		pause = true
-		romPath = os.Args[2]
-	} else {
-		pause = false
-		romPath = os.Args[1]
-	}
-	mem.LoadRom(romPath)
+	err = termbox.Init()
 	if err != nil {
 		panic(err)
 	}
@@ -42,49 +252,109 @@ func main() {
 			eventQueue <- termbox.PollEvent()
 		}
 	}()
-	termbox.Flush()
+	// report prints the outcome of a debugger command or of a run in the console
+	report := func(out string, err error) {
+		console.Print(out)
+		if err == chip8.ErrHalted {
+			myLogger.InfoPrint("Program exited")
+			console.Print("program exited")
+		} else if err != nil {
+			myLogger.ErrorPrint(err.Error())
+			console.Print(err.Error())
+		}
+	}
+	// do runs f on the chip8 in the machine goroutine and reports its error
+	do := func(f func(m *chip8.Memory) error) {
+		var err error
+		if doErr := mach.Do(func(d *debugger.Debugger) { err = f(d.Memory()) }); doErr != nil {
+			err = doErr
+		}
+		report("", err)
+	}
+	redraw := func() {
+		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+		graphics.PrintMemoryValues(snapshot)
+		graphics.PrintString(statusX, statusY, termbox.ColorDefault, termbox.ColorDefault, status)
+		console.Draw()
+		termbox.Flush()
+	}
+	redraw()
 loop:
 	for {
-		termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
 		select {
-		case ev := <-eventQueue:
-			if ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc {
+		case ev, ok := <-mach.Events():
+			if !ok {
 				break loop
 			}
-			if ev.Type == termbox.EventKey && ev.Key == termbox.KeySpace {
-				pause = !pause
+			switch ev.Kind {
+			case machine.FrameReady:
+				snapshot, running, status = ev.Snapshot, ev.Running, ev.Status
+			case machine.Paused:
+				report(ev.Message, nil)
+			case machine.Halted:
+				report("", ev.Err)
 			}
-			if ev.Type == termbox.EventKey {
-				str := string(ev.Ch)
-				if str >= "A" {
-					str = strings.ToLower(str)
+			redraw()
+		case ev := <-eventQueue:
+			if ev.Type != termbox.EventKey {
+				break
+			}
+			if console.Active {
+				if line, ok := console.HandleKey(ev); ok {
+					console.Print(":" + line)
+					report(mach.Exec(line))
 				}
-				switch str {
-				case "s":
-					mem.PrintMemoryValues()
-					mem.Iterate()
-					termbox.Flush()
-					break
-				case "a":
-					myLogger.InfoPrint("Reloading/pausing emulator")
-					mem = chip8.Memory{}
-					mem.Init()
-					mem.LoadRom(romPath)
-					pause = true
-					termbox.Flush()
-					break
-				case "q":
-					myLogger.InfoPrint("Dump")
-					break
+				redraw()
+				break
+			}
+			terminal.HandleEvent(ev)
+			if ev.Key == termbox.KeyEsc {
+				break loop
+			}
+			if ev.Key == termbox.KeySpace {
+				if running {
+					report("", mach.Pause())
+				} else {
+					report("", mach.Resume())
 				}
 			}
-		default:
-			if !pause {
-				mem.PrintMemoryValues()
-				mem.Iterate()
-				termbox.Flush()
-				time.Sleep(10 * time.Millisecond)
+			if ev.Key == turboKey {
+				report("", mach.ToggleSpeed(scheduler.Turbo))
+			}
+			if ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2 {
+				report("", mach.RewindFrame())
+			}
+			if slot, ok := saveKeys[ev.Key]; ok {
+				path := statePath(romPath, slot)
+				do(func(m *chip8.Memory) error { return m.SaveStateFile(path) })
+			}
+			if slot, ok := loadKeys[ev.Key]; ok {
+				path := statePath(romPath, slot)
+				do(func(m *chip8.Memory) error { return m.LoadStateFile(path) })
+			}
+			str := string(ev.Ch)
+			if str >= "A" {
+				str = strings.ToLower(str)
+			}
+			switch str {
+			case ":":
+				console.Active = true
+			case "s":
+				report(mach.Exec("step"))
+			case "z":
+				report(mach.Exec("back"))
+			case "a":
+				myLogger.InfoPrint("Reloading/pausing emulator")
+				report("", mach.Reset())
+				report("", mach.Pause())
+			case "q":
+				myLogger.InfoPrint("Dump")
+			case slowKey:
+				report("", mach.ToggleSpeed(scheduler.Slow))
+			case frameKey:
+				report("", mach.AdvanceFrame())
 			}
+			redraw()
 		}
 	}
 }
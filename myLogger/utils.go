@@ -3,6 +3,7 @@ package myLogger
 import (
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 )
@@ -111,3 +112,11 @@ func Init(b bool) error {
 		log.Ltime|log.Lshortfile)
 	return nil
 }
+
+// Discard silences every output,
+// for the tests running too many instructions to log them
+func Discard() {
+	verbose = false
+	discard := log.New(ioutil.Discard, "", 0)
+	Trace, Info, Warning, Error = discard, discard, discard, discard
+}
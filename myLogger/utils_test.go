@@ -271,6 +271,20 @@ func (suite *LoggerTestSuite)TestErrorVerbosePrint_without_verbose_activated(){
 	assert.False(suite.T(), strings.Contains(str, "asd"), "Print Message")
 }
 
+func (suite *LoggerTestSuite)TestDiscard(){
+	// Adapt
+	err := Init(true)
+
+	// Act
+	Discard()
+	InfoPrint("asd")
+	ErrorPrint("asd")
+
+	// Assert
+	_, str := printCheck()
+	assert.Nil(suite.T(), err, "Return Nil")
+	assert.False(suite.T(), strings.Contains(str, "asd"), "Print Message")
+}
 
 func TestLoggerTestSuite(t *testing.T) {
 	suite.Run(t, new(LoggerTestSuite))
@@ -0,0 +1,222 @@
+// Package scheduler paces a chip8 in real time: it runs a number of
+// instructions per 60 Hz frame and sleeps until the next frame
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// FrameRate is the number of frames per second, the timers tick once a frame
+const FrameRate = chip8.TimerFrequency
+
+// FrameDuration is the duration of a frame at normal speed
+const FrameDuration = time.Second / FrameRate
+
+// DefaultIPS is the number of instructions per second when none is set
+const DefaultIPS = 700
+
+// MaxLag is how late a frame can be before the scheduler stops catching up,
+// the missed time is then dropped instead of running the frames in a burst
+const MaxLag = 100 * time.Millisecond
+
+// Speed scales the duration of the frames
+type Speed int
+
+// The speeds: Turbo runs TurboFactor frames in the time of one,
+// Slow runs a frame in the time of SlowFactor
+const (
+	Normal Speed = iota
+	Turbo
+	Slow
+)
+
+// TurboFactor and SlowFactor are the speed changes of Turbo and Slow
+const (
+	TurboFactor = 4
+	SlowFactor  = 4
+)
+
+var speedNames = [...]string{"normal", "turbo", "slow"}
+
+// String returns the name of the speed
+func (s Speed) String() string {
+	if s < 0 || int(s) >= len(speedNames) {
+		return fmt.Sprintf("Speed(%d)", int(s))
+	}
+	return speedNames[s]
+}
+
+// Scheduler runs IPS instructions per second in FrameRate frames per second.
+// The deadline of a frame follows from the deadline of the previous one,
+// so the time lost in a sleep is taken back on the next frame.
+type Scheduler struct {
+	// IPS is the number of instructions per second at normal speed
+	IPS int
+	// VIP replaces IPS by the timing of the COSMAC VIP: a frame runs the
+	// instructions that fit in chip8.VIPCyclesPerFrame machine cycles
+	VIP bool
+	// Speed is the current speed
+	Speed Speed
+	// Now and Sleep default to time.Now, whose monotonic reading
+	// is used to measure the durations, and time.Sleep
+	Now   func() time.Time
+	Sleep func(time.Duration)
+
+	frames uint64
+	next   time.Time
+	// carry is the VIP cycles the previous frame took past its budget
+	carry int
+
+	windowStart              time.Time
+	windowCycles             uint64
+	windowFrames             int
+	measuredIPS, measuredFPS float64
+}
+
+// New creates a Scheduler of ips instructions per second
+func New(ips int) *Scheduler {
+	return &Scheduler{IPS: ips, Now: time.Now, Sleep: time.Sleep}
+}
+
+// FromCyclesPerFrame returns the instructions per second
+// of a number of instructions per frame
+func FromCyclesPerFrame(cycles int) int {
+	return cycles * FrameRate
+}
+
+// FrameCycles returns the number of instructions of the next frame,
+// IPS is spread evenly on the frames when it is not a multiple of FrameRate
+func (s *Scheduler) FrameCycles() int {
+	if s.IPS <= 0 {
+		return 0
+	}
+	ips := uint64(s.IPS)
+	frame := s.frames % FrameRate
+	return int((frame+1)*ips/FrameRate - frame*ips/FrameRate)
+}
+
+// RunFrame runs the instructions of the next frame and returns how many ran.
+// step runs an instruction and returns true, or returns false without running
+// one to end the frame early. In VIP mode the instructions are charged their
+// chip8.VIPCycles, the cycles taken past the budget are taken from the next
+// frame, and a draw waits for the next frame unless it is the first instruction.
+func (s *Scheduler) RunFrame(m *chip8.Memory, step func() bool) int {
+	done := 0
+	if !s.VIP {
+		cycles := s.FrameCycles()
+		for done < cycles && step() {
+			done++
+		}
+		return done
+	}
+	budget := s.carry + chip8.VIPCyclesPerFrame
+	s.carry = 0
+	for budget > 0 {
+		if done > 0 && m.VIPWaitsDisplay() {
+			// the rest of the frame is lost waiting for the display
+			break
+		}
+		cost := m.VIPCycles()
+		if !step() {
+			break
+		}
+		budget -= cost
+		done++
+	}
+	if budget < 0 {
+		s.carry = budget
+	}
+	return done
+}
+
+// frameDuration returns the duration of a frame at the current speed
+func (s *Scheduler) frameDuration() time.Duration {
+	switch s.Speed {
+	case Turbo:
+		return FrameDuration / TurboFactor
+	case Slow:
+		return FrameDuration * SlowFactor
+	}
+	return FrameDuration
+}
+
+// Toggle switches to the speed, or back to Normal when it is the current one
+func (s *Scheduler) Toggle(speed Speed) {
+	if s.Speed == speed {
+		speed = Normal
+	}
+	s.Speed = speed
+	s.next = time.Time{}
+}
+
+// EndFrame records a frame that ran the given number of instructions
+func (s *Scheduler) EndFrame(cycles int) {
+	s.frames++
+	now := s.Now()
+	if s.windowStart.IsZero() {
+		// the measures start at the end of the first frame
+		s.windowStart = now
+		return
+	}
+	s.windowCycles += uint64(cycles)
+	s.windowFrames++
+	if elapsed := now.Sub(s.windowStart); elapsed >= time.Second {
+		s.measuredIPS = float64(s.windowCycles) / elapsed.Seconds()
+		s.measuredFPS = float64(s.windowFrames) / elapsed.Seconds()
+		s.windowStart, s.windowCycles, s.windowFrames = now, 0, 0
+	}
+}
+
+// Wait sleeps until the start of the next frame
+func (s *Scheduler) Wait() {
+	if d := s.Delay(); d > 0 {
+		s.Sleep(d)
+	}
+}
+
+// Delay moves to the next frame and returns the time until its start,
+// it is Wait for the callers doing their own sleeping
+func (s *Scheduler) Delay() time.Duration {
+	now := s.Now()
+	if s.next.IsZero() || now.Sub(s.next) > MaxLag {
+		s.next = now
+	}
+	s.next = s.next.Add(s.frameDuration())
+	if d := s.next.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Reset forgets the frame deadline and the measures,
+// it is called when the emulation stops so that it does not catch up on resume
+func (s *Scheduler) Reset() {
+	s.next = time.Time{}
+	s.carry = 0
+	s.windowStart, s.windowCycles, s.windowFrames = time.Time{}, 0, 0
+	s.measuredIPS, s.measuredFPS = 0, 0
+}
+
+// Measured returns the instructions and frames per second
+// actually run during the last second, both are 0 until a second passed
+func (s *Scheduler) Measured() (ips, fps float64) {
+	return s.measuredIPS, s.measuredFPS
+}
+
+// String returns the measured speed, the target and the speed mode
+func (s *Scheduler) String() string {
+	ips, fps := s.Measured()
+	target := strconv.Itoa(s.IPS)
+	if s.VIP {
+		target = "VIP"
+	}
+	status := fmt.Sprintf("%.0f/%s IPS %.1f FPS", ips, target, fps)
+	if s.Speed != Normal {
+		status += " " + s.Speed.String()
+	}
+	return status
+}
@@ -0,0 +1,253 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchedulerTestSuite struct {
+	suite.Suite
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (suite *SchedulerTestSuite) SetupTest() {
+	myLogger.Init(true)
+	suite.now = time.Unix(1000, 0)
+	suite.sleeps = nil
+}
+
+// createScheduler returns a scheduler on a fake clock, a sleep moves the clock
+func (suite *SchedulerTestSuite) createScheduler(ips int) *Scheduler {
+	s := New(ips)
+	s.Now = func() time.Time { return suite.now }
+	s.Sleep = func(d time.Duration) {
+		suite.sleeps = append(suite.sleeps, d)
+		suite.now = suite.now.Add(d)
+	}
+	return s
+}
+
+// frame runs a frame of the scheduler that lasts work
+func (suite *SchedulerTestSuite) frame(s *Scheduler, work time.Duration) int {
+	cycles := s.FrameCycles()
+	suite.now = suite.now.Add(work)
+	s.EndFrame(cycles)
+	s.Wait()
+	return cycles
+}
+
+func (suite *SchedulerTestSuite) TestFrameCycles_Spread() {
+	// Adapt
+	s := suite.createScheduler(700)
+	total := 0
+	counts := map[int]bool{}
+
+	// Act
+	for i := 0; i < FrameRate; i++ {
+		cycles := suite.frame(s, 0)
+		counts[cycles] = true
+		total += cycles
+	}
+
+	// Assert
+	assert.Equal(suite.T(), 700, total, "Instructions of a second")
+	assert.Equal(suite.T(), map[int]bool{11: true, 12: true}, counts, "11 or 12 instructions a frame")
+}
+
+func (suite *SchedulerTestSuite) TestFrameCycles_CyclesPerFrame() {
+	// Adapt
+	s := suite.createScheduler(FromCyclesPerFrame(11))
+
+	// Act
+	cycles := []int{suite.frame(s, 0), suite.frame(s, 0), suite.frame(s, 0)}
+
+	// Assert
+	assert.Equal(suite.T(), []int{11, 11, 11}, cycles, "Same instructions every frame")
+}
+
+func (suite *SchedulerTestSuite) TestWait_Drift() {
+	// Adapt
+	s := suite.createScheduler(600)
+	start := suite.now
+
+	// Act
+	for i := 0; i < FrameRate; i++ {
+		suite.frame(s, 3*time.Millisecond)
+	}
+
+	// Assert
+	assert.Equal(suite.T(), FrameRate*FrameDuration+3*time.Millisecond, suite.now.Sub(start), "A second for 60 frames")
+	assert.Equal(suite.T(), FrameDuration-3*time.Millisecond, suite.sleeps[1], "Work taken from the sleep")
+}
+
+func (suite *SchedulerTestSuite) TestWait_LateFrame() {
+	// Adapt
+	s := suite.createScheduler(600)
+	suite.frame(s, 0)
+
+	// Act
+	suite.frame(s, FrameDuration+FrameDuration/2)
+	late := len(suite.sleeps)
+	suite.frame(s, 0)
+	suite.frame(s, 2*MaxLag)
+
+	// Assert
+	assert.Equal(suite.T(), 1, late, "A late frame does not sleep")
+	assert.Equal(suite.T(), FrameDuration/2, suite.sleeps[1], "Catch up on the next frame")
+	assert.Equal(suite.T(), FrameDuration, suite.sleeps[2], "Too late, the lag is dropped")
+}
+
+func (suite *SchedulerTestSuite) TestDelay() {
+	// Adapt
+	s := suite.createScheduler(600)
+
+	// Act
+	first := s.Delay()
+	suite.now = suite.now.Add(FrameDuration + time.Millisecond)
+	late := s.Delay()
+
+	// Assert
+	assert.Equal(suite.T(), FrameDuration, first, "A frame")
+	assert.Equal(suite.T(), FrameDuration-time.Millisecond, late, "Shorter after a late frame")
+	assert.Empty(suite.T(), suite.sleeps, "No sleep")
+}
+
+func (suite *SchedulerTestSuite) TestToggle() {
+	// Adapt
+	s := suite.createScheduler(600)
+
+	// Act
+	s.Toggle(Turbo)
+	suite.frame(s, 0)
+	turbo := suite.sleeps[0]
+	s.Toggle(Slow)
+	suite.frame(s, 0)
+	slow := suite.sleeps[1]
+	s.Toggle(Slow)
+
+	// Assert
+	assert.Equal(suite.T(), FrameDuration/TurboFactor, turbo, "Turbo frame")
+	assert.Equal(suite.T(), FrameDuration*SlowFactor, slow, "Slow frame")
+	assert.Equal(suite.T(), Normal, s.Speed, "Back to normal")
+}
+
+func (suite *SchedulerTestSuite) TestMeasured() {
+	// Adapt
+	s := suite.createScheduler(700)
+
+	// Act
+	for i := 0; i <= 2*FrameRate; i++ {
+		suite.frame(s, 0)
+	}
+	ips, fps := s.Measured()
+	s.Reset()
+	resetIPS, _ := s.Measured()
+
+	// Assert
+	assert.InDelta(suite.T(), 700, ips, 1, "Measured instructions per second")
+	assert.InDelta(suite.T(), 60, fps, 0.1, "Measured frames per second")
+	assert.Equal(suite.T(), 0.0, resetIPS, "No measure after a reset")
+	assert.Equal(suite.T(), "0/700 IPS 0.0 FPS", s.String(), "Status")
+}
+
+// createVIPMem creates a chip8 whose program is the opcodes then additions
+func createVIPMem(opcodes ...uint16) *chip8.Memory {
+	m := chip8.NewSeededMemory(chip8.QuirksVIP, 1)
+	for i := 0x200; i < m.MemorySize(); i += 2 {
+		m.Memory[i], m.Memory[i+1] = 0x70, 0x01
+	}
+	for i, opcode := range opcodes {
+		m.Memory[0x200+2*i], m.Memory[0x201+2*i] = byte(opcode>>8), byte(opcode)
+	}
+	return m
+}
+
+// iterate returns a step running the instructions of m
+func (suite *SchedulerTestSuite) iterate(m *chip8.Memory) func() bool {
+	return func() bool {
+		assert.Nil(suite.T(), m.Iterate(), "Instruction")
+		return true
+	}
+}
+
+func (suite *SchedulerTestSuite) TestRunFrame() {
+	// Adapt
+	s := suite.createScheduler(FromCyclesPerFrame(11))
+	m := createVIPMem()
+	steps := 0
+
+	// Act
+	done := s.RunFrame(m, suite.iterate(m))
+	stopped := s.RunFrame(m, func() bool {
+		steps++
+		return steps <= 3
+	})
+
+	// Assert
+	assert.Equal(suite.T(), 11, done, "Instructions of a frame")
+	assert.Equal(suite.T(), 3, stopped, "Frame ended by the step")
+}
+
+func (suite *SchedulerTestSuite) TestRunFrame_VIP() {
+	// Adapt
+	s := suite.createScheduler(DefaultIPS)
+	s.VIP = true
+	m := createVIPMem()
+
+	// Act
+	first := s.RunFrame(m, suite.iterate(m))
+	carry := s.carry
+	second := s.RunFrame(m, suite.iterate(m))
+
+	// Assert
+	assert.Equal(suite.T(), 37, first, "Additions of 50 cycles in a frame")
+	assert.Equal(suite.T(), chip8.VIPCyclesPerFrame-37*50, carry, "Last addition past the budget")
+	assert.Equal(suite.T(), 37, second, "Carry taken from the budget")
+	assert.Equal(suite.T(), byte(74), m.V[0], "All the additions ran")
+	assert.Equal(suite.T(), "0/VIP IPS 0.0 FPS", s.String(), "Status")
+}
+
+func (suite *SchedulerTestSuite) TestRunFrame_VIPDisplayWait() {
+	// Adapt
+	s := suite.createScheduler(DefaultIPS)
+	s.VIP = true
+	m := createVIPMem(0x7001, 0xD011, 0xD011)
+
+	// Act
+	before := s.RunFrame(m, suite.iterate(m))
+	draws := s.RunFrame(m, suite.iterate(m))
+
+	// Assert
+	assert.Equal(suite.T(), 1, before, "Draw waits for the next frame")
+	assert.Equal(suite.T(), 1, draws, "One draw a frame")
+	assert.Equal(suite.T(), 0, s.carry, "Rest of the frame lost")
+}
+
+func (suite *SchedulerTestSuite) TestRunFrame_VIPLongInstruction() {
+	// Adapt
+	s := suite.createScheduler(DefaultIPS)
+	s.VIP = true
+	m := createVIPMem(0x00E0, 0x00E0)
+
+	// Act
+	first := s.RunFrame(m, suite.iterate(m))
+	second := s.RunFrame(m, suite.iterate(m))
+	waiting := s.RunFrame(m, suite.iterate(m))
+	s.Reset()
+	reset := s.RunFrame(m, suite.iterate(m))
+
+	// Assert
+	assert.Equal(suite.T(), []int{1, 1}, []int{first, second}, "Clears past the budget")
+	assert.Equal(suite.T(), 0, waiting, "Frame taken by the clears")
+	assert.NotZero(suite.T(), reset, "Reset drops the carry")
+}
+
+func TestSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerTestSuite))
+}
@@ -0,0 +1,148 @@
+; flags.asm checks VF after the arithmetic instructions,
+; including when VF is an operand, the flag is written after the result
+
+        LD VC, 0
+        LD VD, 0
+
+        LD V1, 10
+        LD V5, 20
+        ADD V1, V5
+        LD V3, VF
+        LD V2, 30
+        LD V4, 0
+        CALL check      ; 8XY4 without carry clears VF
+
+        LD V1, 0xFF
+        LD V5, 2
+        ADD V1, V5
+        LD V3, VF
+        LD V2, 1
+        LD V4, 1
+        CALL check      ; 8XY4 with carry sets VF
+
+        LD V1, 20
+        LD V5, 10
+        SUB V1, V5
+        LD V3, VF
+        LD V2, 10
+        LD V4, 1
+        CALL check      ; 8XY5 without borrow sets VF
+
+        LD V1, 5
+        LD V5, 5
+        SUB V1, V5
+        LD V3, VF
+        LD V2, 0
+        LD V4, 1
+        CALL check      ; 8XY5 of equal values sets VF
+
+        LD V1, 10
+        LD V5, 20
+        SUB V1, V5
+        LD V3, VF
+        LD V2, 0xF6
+        LD V4, 0
+        CALL check      ; 8XY5 with borrow clears VF
+
+        LD V1, 10
+        LD V5, 20
+        SUBN V1, V5
+        LD V3, VF
+        LD V2, 10
+        LD V4, 1
+        CALL check      ; 8XY7 without borrow sets VF
+
+        LD V1, 20
+        LD V5, 10
+        SUBN V1, V5
+        LD V3, VF
+        LD V2, 0xF6
+        LD V4, 0
+        CALL check      ; 8XY7 with borrow clears VF
+
+        LD V5, 5
+        LD V1, V5
+        SHR V1, V5
+        LD V3, VF
+        LD V2, 2
+        LD V4, 1
+        CALL check      ; 8XY6 puts the shifted out bit 1 in VF
+
+        LD V5, 4
+        LD V1, V5
+        SHR V1, V5
+        LD V3, VF
+        LD V2, 2
+        LD V4, 0
+        CALL check      ; 8XY6 puts the shifted out bit 0 in VF
+
+        LD V5, 0x81
+        LD V1, V5
+        SHL V1, V5
+        LD V3, VF
+        LD V2, 0x02
+        LD V4, 1
+        CALL check      ; 8XYE puts the shifted out bit 1 in VF
+
+        LD V5, 0x41
+        LD V1, V5
+        SHL V1, V5
+        LD V3, VF
+        LD V2, 0x82
+        LD V4, 0
+        CALL check      ; 8XYE puts the shifted out bit 0 in VF
+
+        LD VF, 0x10
+        LD V5, 0x20
+        ADD VF, V5
+        LD V1, VF
+        LD V2, 0
+        CALL expect     ; 8XY4 on VF leaves the flag in VF
+
+        LD VF, 0x30
+        LD V5, 0x10
+        SUB VF, V5
+        LD V1, VF
+        LD V2, 1
+        CALL expect     ; 8XY5 on VF leaves the flag in VF
+
+        LD VF, 0x10
+        LD V5, 0x30
+        SUBN VF, V5
+        LD V1, VF
+        LD V2, 1
+        CALL expect     ; 8XY7 on VF leaves the flag in VF
+
+        LD V5, 4
+        LD VF, V5
+        SHR VF, V5
+        LD V1, VF
+        LD V2, 0
+        CALL expect     ; 8XY6 on VF leaves the flag in VF
+
+        LD V5, 0x40
+        LD VF, V5
+        SHL VF, V5
+        LD V1, VF
+        LD V2, 0
+        CALL expect     ; 8XYE on VF leaves the flag in VF
+
+        LD V1, 1
+        LD VF, 0xFF
+        ADD V1, VF
+        LD V3, VF
+        LD V2, 0
+        LD V4, 1
+        CALL check      ; 8XY4 reads VF as VY before setting the flag
+
+        LD V1, 0x30
+        LD VF, 0x10
+        SUB V1, VF
+        LD V3, VF
+        LD V2, 0x20
+        LD V4, 1
+        CALL check      ; 8XY5 reads VF as VY before setting the flag
+
+done:   JP done
+
+INCLUDE "report.asm"
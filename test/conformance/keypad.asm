@@ -0,0 +1,45 @@
+; keypad.asm checks the key instructions, the test presses the key 5
+; on frame 10 and releases it on frame 15, then holds the key 7 from frame 30
+
+        LD VC, 0
+        LD VD, 0
+
+        LD V5, 5
+        LD V1, 0
+        SKP V5
+        LD V1, 1
+        LD V2, 1
+        CALL expect     ; EX9E does not skip when the key is up
+
+        LD V1, 0
+        SKNP V5
+        LD V1, 1
+        LD V2, 0
+        CALL expect     ; EXA1 skips when the key is up
+
+        LD V1, K
+        LD V2, 5
+        CALL expect     ; FX0A waits for a key and stores it
+
+        LD V1, 0
+        SKNP V5
+        LD V1, 1
+        LD V2, 0
+        CALL expect     ; FX0A returns once the key is released
+
+        LD V5, 7
+wait:   SKP V5
+        JP wait
+        LD V1, 1
+        LD V2, 1
+        CALL expect     ; EX9E skips when the key is down
+
+        LD V1, 0
+        SKNP V5
+        LD V1, 1
+        LD V2, 1
+        CALL expect     ; EXA1 does not skip when the key is down
+
+done:   JP done
+
+INCLUDE "report.asm"
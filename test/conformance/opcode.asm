@@ -0,0 +1,217 @@
+; opcode.asm checks the instructions of the CHIP-8,
+; each CALL of expect or check reports the check named by its comment
+
+        LD VC, 0
+        LD VD, 0
+
+        ; draw twice in a corner out of the marks, after a CLS
+        ; the second draw must not collide
+        LD V5, 60
+        LD V6, 28
+        LD I, block
+        DRW V5, V6, 4
+        CLS
+        DRW V5, V6, 4
+        LD V1, VF
+        DRW V5, V6, 4
+        LD V3, VF
+        LD V2, 0
+        LD V4, 1
+        CALL check      ; 00E0 clears the screen and DXYN sets VF on collision
+
+        LD V1, 0
+        JP jumped
+        LD V1, 1
+jumped: LD V2, 0
+        CALL expect     ; 1NNN jumps
+
+        LD V1, 0
+        CALL sub
+        ADD V1, 1
+        LD V2, 2
+        CALL expect     ; 2NNN calls and 00EE returns after the call
+
+        LD V1, 0
+        LD V5, 7
+        SE V5, 7
+        LD V1, 1
+        SE V5, 8
+        ADD V1, 2
+        LD V2, 2
+        CALL expect     ; 3XNN skips only when VX equals NN
+
+        LD V1, 0
+        SNE V5, 8
+        LD V1, 1
+        SNE V5, 7
+        ADD V1, 2
+        LD V2, 2
+        CALL expect     ; 4XNN skips only when VX differs from NN
+
+        LD V1, 0
+        LD V6, 7
+        SE V5, V6
+        LD V1, 1
+        LD V6, 8
+        SE V5, V6
+        ADD V1, 2
+        LD V2, 2
+        CALL expect     ; 5XY0 skips only when VX equals VY
+
+        LD V1, 0
+        SNE V5, V6
+        LD V1, 1
+        LD V6, 7
+        SNE V5, V6
+        ADD V1, 2
+        LD V2, 2
+        CALL expect     ; 9XY0 skips only when VX differs from VY
+
+        LD V1, 0x42
+        LD V2, 0x42
+        CALL expect     ; 6XNN loads NN
+
+        LD VF, 5
+        LD V1, 0xFF
+        ADD V1, 2
+        LD V2, 1
+        LD V3, VF
+        LD V4, 5
+        CALL check      ; 7XNN adds and wraps without changing VF
+
+        LD V5, 0x3C
+        LD V1, V5
+        LD V2, 0x3C
+        CALL expect     ; 8XY0 copies VY
+
+        LD V1, 0x0C
+        LD V5, 0x30
+        OR V1, V5
+        LD V2, 0x3C
+        CALL expect     ; 8XY1 ors
+
+        LD V1, 0x3C
+        LD V5, 0x0F
+        AND V1, V5
+        LD V2, 0x0C
+        CALL expect     ; 8XY2 ands
+
+        LD V1, 0x3C
+        LD V5, 0x0F
+        XOR V1, V5
+        LD V2, 0x33
+        CALL expect     ; 8XY3 xors
+
+        LD V1, 0x40
+        LD V5, 0x23
+        ADD V1, V5
+        LD V2, 0x63
+        CALL expect     ; 8XY4 adds
+
+        LD V1, 0x40
+        LD V5, 0x23
+        SUB V1, V5
+        LD V2, 0x1D
+        CALL expect     ; 8XY5 subtracts VY from VX
+
+        LD V1, 0x23
+        LD V5, 0x40
+        SUBN V1, V5
+        LD V2, 0x1D
+        CALL expect     ; 8XY7 subtracts VX from VY
+
+        LD V5, 0x86
+        LD V1, V5
+        SHR V1, V5
+        LD V2, 0x43
+        CALL expect     ; 8XY6 shifts right
+
+        LD V5, 0x43
+        LD V1, V5
+        SHL V1, V5
+        LD V2, 0x86
+        CALL expect     ; 8XYE shifts left
+
+        LD I, 0x310
+        LD V0, 0xAB
+        LD [I], V0
+        LD I, 0x300
+        LD V5, 0x10
+        ADD I, V5
+        LD V0, [I]
+        LD V1, V0
+        LD V2, 0xAB
+        CALL expect     ; ANNN loads I and FX1E adds VX to I
+
+        LD V0, 2
+        JP V0, table
+table:  JP badjump
+        JP goodjump
+badjump:
+        LD V1, 0
+        JP jumpdone
+goodjump:
+        LD V1, 1
+jumpdone:
+        LD V2, 1
+        CALL expect     ; BNNN jumps to NNN plus V0
+
+        RND V1, 0x0F
+        LD V5, 0xF0
+        AND V5, V1
+        LD V1, V5
+        LD V2, 0
+        CALL expect     ; CXNN masks the random number with NN
+
+        LD V5, 234
+        LD I, 0x300
+        LD B, V5
+        LD V2, [I]
+        LD V5, V2
+        LD V3, V0
+        LD V4, 2
+        LD V2, 3
+        CALL check      ; FX33 stores the hundreds and the tens
+        LD V1, V5
+        LD V2, 4
+        CALL expect     ; FX33 stores the units
+
+        LD V0, 1
+        LD V1, 2
+        LD V2, 3
+        LD I, 0x300
+        LD [I], V2
+        LD V0, 0
+        LD V1, 0
+        LD V2, 0
+        LD I, 0x300
+        LD V2, [I]
+        LD V3, V2
+        LD V4, 3
+        LD V2, 2
+        CALL check      ; FX55 stores and FX65 loads V0 to VX
+
+        LD V5, 0xA
+        LD F, V5
+        LD V1, [I]
+        LD V3, V0
+        LD V4, 0xF0
+        LD V2, 0x90
+        CALL check      ; FX29 points I to the font of VX
+
+        LD V5, 3
+        LD DT, V5
+timer:  LD V1, DT
+        SE V1, 0
+        JP timer
+        LD V2, 0
+        CALL expect     ; FX15 loads and FX07 reads the delay timer counting down
+
+done:   JP done
+
+sub:    LD V1, 1
+        RET
+
+block:  SPRITE ####.... ####.... ####.... ####....
+
+INCLUDE "report.asm"
@@ -0,0 +1,110 @@
+; quirks.asm checks the instructions that differ between the platforms,
+; the expected behavior is given by constants defined before including it:
+; VF_RESET is 1 when 8XY1, 8XY2 and 8XY3 clear VF,
+; SHIFT_VY is 1 when 8XY6 and 8XYE shift VY,
+; LOAD_STORE is 0 when FX55 and FX65 leave I unchanged,
+; 1 when they add X + 1 to I and 2 when they add X,
+; JUMP_VX is 1 when BXNN jumps to XNN + VX and
+; CLIP is 1 when DXYN clips the sprites at the edge of the screen
+
+        LD VC, 0
+        LD VD, 0
+
+        LD V6, VF_RESET
+        LD V2, 5
+        SNE V6, 1
+        LD V2, 0
+        LD VF, 5
+        LD V5, 1
+        OR V5, V5
+        LD V1, VF
+        LD VF, 5
+        AND V5, V5
+        LD V7, VF
+        LD VF, 5
+        XOR V5, V5
+        LD V8, VF
+        CALL expect     ; 8XY1 leaves VF as expected
+        LD V1, V7
+        CALL expect     ; 8XY2 leaves VF as expected
+        LD V1, V8
+        CALL expect     ; 8XY3 leaves VF as expected
+
+        LD V6, SHIFT_VY
+        LD V2, 8
+        SNE V6, 1
+        LD V2, 2
+        LD V1, 0x10
+        LD V5, 0x04
+        SHR V1, V5
+        CALL expect     ; 8XY6 shifts the expected register
+        LD V2, 0x20
+        SNE V6, 1
+        LD V2, 0x08
+        LD V1, 0x10
+        SHL V1, V5
+        CALL expect     ; 8XYE shifts the expected register
+
+        LD I, 0x303
+        LD V0, 0xB3
+        LD [I], V0
+        LD V0, 0xA0
+        LD V1, 0xA1
+        LD V2, 0xA2
+        LD I, 0x300
+        LD [I], V2
+        LD V0, [I]
+        LD V1, V0
+        LD V6, LOAD_STORE
+        LD V2, 0xA0
+        SNE V6, 1
+        LD V2, 0xB3
+        SNE V6, 2
+        LD V2, 0xA2
+        CALL expect     ; FX55 moves I as expected
+        LD I, 0x300
+        LD V2, [I]
+        LD V0, [I]
+        LD V1, V0
+        LD V2, 0xA0
+        SNE V6, 1
+        LD V2, 0xB3
+        SNE V6, 2
+        LD V2, 0xA2
+        CALL expect     ; FX65 moves I as expected
+
+        ; the jump table is below 0x400, its high nibble names V2 or V3
+        LD V0, 0
+        LD V2, 2
+        LD V3, 2
+        JP V0, table
+table:  JP novx
+        JP vx
+novx:   LD V1, 0
+        JP jumped
+vx:     LD V1, 1
+jumped: LD V2, JUMP_VX
+        CALL expect     ; BNNN adds the expected register
+
+        ; draw past the right edge on rows out of the marks
+        LD V5, 62
+        LD V6, 4
+        LD I, line
+        DRW V5, V6, 1
+        LD V5, 0
+        LD I, dot
+        DRW V5, V6, 1
+        LD V1, VF
+        DRW V5, V6, 1
+        LD V5, 62
+        LD I, line
+        DRW V5, V6, 1
+        LD V2, 1 - CLIP
+        CALL expect     ; DXYN clips or wraps the sprites as expected
+
+done:   JP done
+
+line:   SPRITE ####....
+dot:    SPRITE #.......
+
+INCLUDE "report.asm"
@@ -0,0 +1,29 @@
+; report.asm draws the results of the checks of a conformance rom:
+; a solid block for a passed check and a cross for a failed one.
+; The marks are 4x4 pixels, 6 pixels apart and 10 on a row,
+; VC and VD hold the position of the next one so the roms must not use them.
+
+; expect passes when V1 == V2
+expect: LD V3, 0
+        LD V4, 0
+; check passes when V1 == V2 and V3 == V4
+check:  LD V0, 0
+        SE V1, V2
+        JP report
+        SE V3, V4
+        JP report
+        LD V0, 1
+; report draws a passed mark when V0 is 1 and a failed one otherwise
+report: LD I, failed
+        SNE V0, 1
+        LD I, passed
+        DRW VC, VD, 4
+        ADD VC, 6
+        SE VC, 60
+        RET
+        LD VC, 0
+        ADD VD, 6
+        RET
+
+passed: SPRITE ####.... ####.... ####.... ####....
+failed: SPRITE #..#.... .##..... .##..... #..#....
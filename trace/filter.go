@@ -0,0 +1,72 @@
+package trace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Filter selects the steps of a trace, its zero value selects them all
+type Filter struct {
+	// From and To select the steps with a PC in the range, To included
+	From, To uint16
+	// Opcode selects the steps matching a pattern, see ParsePattern
+	Opcode *Pattern
+	// Registers selects the steps changing one of these registers
+	Registers []string
+}
+
+// Match tells if the filter selects a step
+func (f Filter) Match(s Step) bool {
+	if (f.From != 0 || f.To != 0) && (s.PC < f.From || s.PC > f.To) {
+		return false
+	}
+	if f.Opcode != nil && !f.Opcode.Match(s.Opcode) {
+		return false
+	}
+	if len(f.Registers) == 0 {
+		return true
+	}
+	for _, r := range s.Registers {
+		for _, name := range f.Registers {
+			if strings.EqualFold(r.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Pattern matches opcodes with wildcard nibbles
+type Pattern struct {
+	mask, value uint16
+}
+
+// ParsePattern parses four nibbles, each one is a hexadecimal digit
+// or one of the wildcards X, Y, N and ?, like 8XY4 or DXYN
+func ParsePattern(s string) (*Pattern, error) {
+	if len(s) != 4 {
+		return nil, fmt.Errorf("pattern %q must be four nibbles like 8XY4", s)
+	}
+	p := &Pattern{}
+	for _, c := range strings.ToUpper(s) {
+		p.mask <<= 4
+		p.value <<= 4
+		switch {
+		case c == 'X' || c == 'Y' || c == 'N' || c == '?':
+		case '0' <= c && c <= '9':
+			p.mask |= 0xF
+			p.value |= uint16(c - '0')
+		case 'A' <= c && c <= 'F':
+			p.mask |= 0xF
+			p.value |= uint16(c - 'A' + 10)
+		default:
+			return nil, fmt.Errorf("pattern %q must be four nibbles like 8XY4", s)
+		}
+	}
+	return p, nil
+}
+
+// Match tells if an opcode matches the pattern
+func (p *Pattern) Match(opcode uint16) bool {
+	return opcode&p.mask == p.value
+}
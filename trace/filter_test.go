@@ -0,0 +1,59 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FilterTestSuite struct {
+	suite.Suite
+}
+
+// step is a DRW at 0x210 changing VF
+var step = Step{PC: 0x210, Opcode: 0xD015, Registers: []Register{{"VF", 1}}}
+
+func (suite *FilterTestSuite) TestZero() {
+	// Assert
+	assert.True(suite.T(), Filter{}.Match(step), "Zero filter selects everything")
+}
+
+func (suite *FilterTestSuite) TestRange() {
+	// Assert
+	assert.True(suite.T(), Filter{From: 0x200, To: 0x210}.Match(step), "To included")
+	assert.False(suite.T(), Filter{From: 0x212, To: 0xFFFF}.Match(step), "Before the range")
+	assert.False(suite.T(), Filter{From: 0x200, To: 0x20E}.Match(step), "After the range")
+}
+
+func (suite *FilterTestSuite) TestOpcode() {
+	// Adapt
+	drw, _ := ParsePattern("dxyn")
+	add, _ := ParsePattern("8XY4")
+	exact, _ := ParsePattern("D?15")
+
+	// Assert
+	assert.True(suite.T(), Filter{Opcode: drw}.Match(step))
+	assert.False(suite.T(), Filter{Opcode: add}.Match(step))
+	assert.True(suite.T(), Filter{Opcode: exact}.Match(step))
+}
+
+func (suite *FilterTestSuite) TestParsePattern_Invalid() {
+	// Act
+	_, short := ParsePattern("DXY")
+	_, letter := ParsePattern("DXYZ")
+
+	// Assert
+	assert.NotNil(suite.T(), short)
+	assert.NotNil(suite.T(), letter)
+}
+
+func (suite *FilterTestSuite) TestRegisters() {
+	// Assert
+	assert.True(suite.T(), Filter{Registers: []string{"V0", "vf"}}.Match(step))
+	assert.False(suite.T(), Filter{Registers: []string{"I"}}.Match(step))
+}
+
+func TestFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(FilterTestSuite))
+}
@@ -0,0 +1,196 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/disasm"
+)
+
+// ErrNotATrace is returned when reading a file which is not a trace
+var ErrNotATrace = errors.New("trace: not a trace")
+
+// Register is the new value of a register changed by a step
+type Register struct {
+	Name  string
+	Value uint16
+}
+
+// Write is memory written by a step
+type Write struct {
+	Address uint16
+	Data    []byte
+}
+
+// Step is an instruction executed by the chip8
+type Step struct {
+	Cycle     uint64
+	PC        uint16
+	Opcode    uint16
+	Mnemonic  string
+	Registers []Register
+	Writes    []Write
+	// Long tells if the step is the XO-CHIP F000 NNNN instruction,
+	// Operand is then its NNNN address
+	Long    bool
+	Operand uint16
+}
+
+// String returns the step as a line of text,
+// the same steps always give the same line so traces can be diffed
+func (s Step) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%010d 0x%04X %04X ", s.Cycle, s.PC, s.Opcode)
+	if s.Long {
+		fmt.Fprintf(&b, "%04X ", s.Operand)
+	}
+	fmt.Fprintf(&b, "%-16s", s.Mnemonic)
+	for _, r := range s.Registers {
+		if r.Name == "I" || r.Name == "SP" {
+			fmt.Fprintf(&b, " %s=0x%04X", r.Name, r.Value)
+		} else {
+			fmt.Fprintf(&b, " %s=0x%02X", r.Name, r.Value)
+		}
+	}
+	for _, w := range s.Writes {
+		fmt.Fprintf(&b, " [0x%04X]=% X", w.Address, w.Data)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Reader reads the steps of a trace
+type Reader struct {
+	r      *bufio.Reader
+	quirks chip8.Quirks
+	cycle  uint64
+}
+
+// NewReader reads the header of a trace
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	var header struct {
+		Magic    [4]byte
+		Version  byte
+		Platform byte
+		Cycle    uint64
+	}
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotATrace
+		}
+		return nil, err
+	}
+	if header.Magic != magic {
+		return nil, ErrNotATrace
+	}
+	if header.Version != Version {
+		return nil, fmt.Errorf("trace: unsupported version %d", header.Version)
+	}
+	return &Reader{
+		r:     br,
+		cycle: header.Cycle,
+		quirks: chip8.Quirks{
+			SuperChip: header.Platform&platformSuperChip != 0,
+			XOChip:    header.Platform&platformXOChip != 0,
+			JumpVX:    header.Platform&platformJumpVX != 0,
+		},
+	}, nil
+}
+
+// Next returns the next step, or io.EOF at the end of the trace
+func (t *Reader) Next() (Step, error) {
+	gap, err := binary.ReadVarint(t.r)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Step{Cycle: t.cycle}, fmt.Errorf("trace: truncated step %d", t.cycle)
+		}
+		return Step{Cycle: t.cycle}, err
+	}
+	t.cycle += uint64(gap)
+	step := Step{Cycle: t.cycle}
+	var head [4]byte
+	if _, err := io.ReadFull(t.r, head[:]); err != nil {
+		return step, fmt.Errorf("trace: truncated step %d", t.cycle)
+	}
+	step.PC = binary.BigEndian.Uint16(head[:2])
+	step.Opcode = binary.BigEndian.Uint16(head[2:])
+	instruction := head[2:]
+	if isLong(step.Opcode, t.quirks) {
+		if step.Operand, err = t.read(2); err != nil {
+			return step, err
+		}
+		step.Long = true
+		instruction = append(instruction, byte(step.Operand>>8), byte(step.Operand))
+	}
+	step.Mnemonic = disasm.Decode(instruction, 0, t.quirks).Mnemonic
+	changed, err := t.readByte()
+	if err != nil {
+		return step, err
+	}
+	for i := 0; i < int(changed); i++ {
+		id, err := t.readByte()
+		if err != nil {
+			return step, err
+		}
+		if int(id) >= registerCount {
+			return step, fmt.Errorf("trace: unknown register %d in step %d", id, t.cycle)
+		}
+		size := 1
+		if isWide(int(id)) {
+			size = 2
+		}
+		value, err := t.read(size)
+		if err != nil {
+			return step, err
+		}
+		step.Registers = append(step.Registers, Register{registerNames[id], value})
+	}
+	writes, err := t.readByte()
+	if err != nil {
+		return step, err
+	}
+	for i := 0; i < int(writes); i++ {
+		address, err := t.read(2)
+		if err != nil {
+			return step, err
+		}
+		size, err := t.readByte()
+		if err != nil {
+			return step, err
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(t.r, data); err != nil {
+			return step, fmt.Errorf("trace: truncated step %d", t.cycle)
+		}
+		step.Writes = append(step.Writes, Write{address, data})
+	}
+	t.cycle++
+	return step, nil
+}
+
+// readByte reads a byte of a step
+func (t *Reader) readByte() (byte, error) {
+	b, err := t.r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("trace: truncated step %d", t.cycle)
+	}
+	return b, nil
+}
+
+// read reads a big-endian value of one or two bytes
+func (t *Reader) read(size int) (uint16, error) {
+	var value uint16
+	for i := 0; i < size; i++ {
+		b, err := t.readByte()
+		if err != nil {
+			return 0, err
+		}
+		value = value<<8 | uint16(b)
+	}
+	return value, nil
+}
@@ -0,0 +1,219 @@
+// Package trace records the instructions executed by a chip8 in a compact
+// binary format and reads them back as text.
+//
+// A trace starts with the magic "C8TR", a version byte, a platform byte
+// and the cycle of the first step. Each step is then made of its cycle as
+// a signed varint of the difference with the cycle following the previous
+// step, zero unless the chip8 was rewound or loaded, its PC and opcode,
+// the address following an XO-CHIP F000, the registers it changed as id
+// and value, and the memory it wrote as address, length and bytes.
+// Every other value is big-endian.
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"github.com/Oicho/GO-Chip8/chip8"
+)
+
+// Version is the version of the trace format
+const Version = 2
+
+// magic starts every trace
+var magic = [4]byte{'C', '8', 'T', 'R'}
+
+// the bits of the platform byte
+const (
+	platformSuperChip = 1 << iota
+	platformXOChip
+	platformJumpVX
+)
+
+// registerCount is the number of registers traced:
+// V0 to VF, I, SP, DT and ST
+const registerCount = 20
+
+// registerNames are the names of the traced registers by id
+var registerNames = [registerCount]string{
+	"V0", "V1", "V2", "V3", "V4", "V5", "V6", "V7",
+	"V8", "V9", "VA", "VB", "VC", "VD", "VE", "VF",
+	"I", "SP", "DT", "ST",
+}
+
+// isWide tells if a register holds 16 bits
+func isWide(id int) bool {
+	return id == 16 || id == 17
+}
+
+// isLong tells if the opcode is the XO-CHIP F000 NNNN instruction,
+// whose NNNN address is traced after it
+func isLong(opcode uint16, q chip8.Quirks) bool {
+	return opcode == 0xF000 && q.XOChip
+}
+
+// registers returns the traced registers of m
+func registers(m *chip8.Memory) [registerCount]uint16 {
+	var r [registerCount]uint16
+	for i, v := range m.V {
+		r[i] = uint16(v)
+	}
+	r[16] = m.I
+	r[17] = m.SP
+	r[18] = uint16(m.DelayTimer)
+	r[19] = uint16(m.SoundTimer)
+	return r
+}
+
+// write is a memory range written by an instruction
+type write struct {
+	address uint16
+	size    uint16
+}
+
+// Recorder writes the steps of a chip8 to a trace,
+// it stops recording once the trace reaches MaxSize bytes
+type Recorder struct {
+	// MaxSize caps the size of the trace, 0 means no cap
+	MaxSize int64
+	// Dropped counts the steps not recorded because of MaxSize
+	Dropped uint64
+
+	w       *bufio.Writer
+	size    int64
+	full    bool
+	started bool
+	err     error
+	// cycle is the cycle following the last step recorded
+	cycle uint64
+
+	// next is the Watcher of the chip8 while the recorder watches it
+	next   chip8.Watcher
+	writes []write
+}
+
+// NewRecorder creates a Recorder writing to w
+func NewRecorder(w io.Writer, maxSize int64) *Recorder {
+	return &Recorder{MaxSize: maxSize, w: bufio.NewWriter(w)}
+}
+
+// Step runs iterate, which executes one instruction of m, and records it.
+// Nothing is recorded if the instruction was not executed.
+func (r *Recorder) Step(m *chip8.Memory, iterate func() error) error {
+	before := registers(m)
+	pc, cycles := m.PC, m.Cycles
+	r.next = m.Watcher
+	r.writes = r.writes[:0]
+	m.Watcher = r
+	err := iterate()
+	m.Watcher = r.next
+	r.next = nil
+	if m.Cycles != cycles {
+		r.record(m, pc, cycles, before)
+	}
+	return err
+}
+
+// Access records the memory written by the instruction and forwards
+// the access to the Watcher of the chip8
+func (r *Recorder) Access(address uint16, size uint16, isWrite bool) {
+	if isWrite {
+		r.writes = append(r.writes, write{address, size})
+	}
+	if r.next != nil {
+		r.next.Access(address, size, isWrite)
+	}
+}
+
+// Flush writes the buffered steps, it returns the first write error
+func (r *Recorder) Flush() error {
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// record encodes the step executed at pc on the given cycle
+func (r *Recorder) record(m *chip8.Memory, pc uint16, cycle uint64, before [registerCount]uint16) {
+	if r.err != nil {
+		return
+	}
+	if !r.started {
+		r.started = true
+		r.header(m, cycle)
+		r.cycle = cycle
+	}
+	var buf [binary.MaxVarintLen64]byte
+	step := buf[:binary.PutVarint(buf[:], int64(cycle-r.cycle))]
+	r.cycle = cycle + 1
+	opcode := uint16(m.Memory[pc])<<8 | uint16(m.Memory[pc+1])
+	step = appendUint16(step, pc)
+	step = appendUint16(step, opcode)
+	if isLong(opcode, m.Quirks) {
+		step = append(step, m.Memory[pc+2], m.Memory[pc+3])
+	}
+	after := registers(m)
+	changed := 0
+	for i := range after {
+		if after[i] != before[i] {
+			changed++
+		}
+	}
+	step = append(step, byte(changed))
+	for i := range after {
+		if after[i] == before[i] {
+			continue
+		}
+		step = append(step, byte(i))
+		if isWide(i) {
+			step = appendUint16(step, after[i])
+		} else {
+			step = append(step, byte(after[i]))
+		}
+	}
+	step = append(step, byte(len(r.writes)))
+	for _, w := range r.writes {
+		step = appendUint16(step, w.address)
+		step = append(step, byte(w.size))
+		step = append(step, m.Memory[w.address:w.address+w.size]...)
+	}
+	r.emit(step)
+}
+
+// header encodes the start of the trace, cycle is the one of its first step
+func (r *Recorder) header(m *chip8.Memory, cycle uint64) {
+	var platform byte
+	if m.Quirks.SuperChip {
+		platform |= platformSuperChip
+	}
+	if m.Quirks.XOChip {
+		platform |= platformXOChip
+	}
+	if m.Quirks.JumpVX {
+		platform |= platformJumpVX
+	}
+	buf := append(append([]byte{}, magic[:]...), Version, platform)
+	buf = appendUint16(buf, uint16(cycle>>48))
+	buf = appendUint16(buf, uint16(cycle>>32))
+	buf = appendUint16(buf, uint16(cycle>>16))
+	buf = appendUint16(buf, uint16(cycle))
+	r.emit(buf)
+}
+
+// appendUint16 appends a big-endian value
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+// emit writes encoded bytes unless the trace is full,
+// once a step did not fit the next ones are dropped too
+func (r *Recorder) emit(buf []byte) {
+	if r.full || r.MaxSize > 0 && r.size+int64(len(buf)) > r.MaxSize {
+		r.full = true
+		r.Dropped++
+		return
+	}
+	r.size += int64(len(buf))
+	_, r.err = r.w.Write(buf)
+}
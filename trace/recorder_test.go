@@ -0,0 +1,222 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Oicho/GO-Chip8/asm"
+	"github.com/Oicho/GO-Chip8/chip8"
+	"github.com/Oicho/GO-Chip8/myLogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecorderTestSuite struct {
+	suite.Suite
+}
+
+func (suite *RecorderTestSuite) SetupTest() {
+	myLogger.Init(true)
+}
+
+// program stores a counter at 0x300 and loops
+const program = `
+loop:   ADD V0, 1
+        LD I, 0x300
+        LD [I], V0
+        JP loop
+`
+
+// createTraceMem creates a chip8 running the program
+func createTraceMem(src string) *chip8.Memory {
+	rom, err := asm.Assemble("test.asm", []byte(src))
+	if err != nil {
+		panic(err)
+	}
+	m := chip8.NewSeededMemory(chip8.QuirksVIP, 1)
+	copy(m.Memory[0x200:], rom)
+	return m
+}
+
+// record runs n instructions of m recording them, it returns the trace
+func record(m *chip8.Memory, n int, maxSize int64) (*Recorder, []byte) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, maxSize)
+	for i := 0; i < n; i++ {
+		r.Step(m, m.Iterate)
+	}
+	r.Flush()
+	return r, buf.Bytes()
+}
+
+// readAll returns the steps of a trace
+func readAll(data []byte) ([]Step, error) {
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var steps []Step
+	for {
+		step, err := r.Next()
+		if err == io.EOF {
+			return steps, nil
+		}
+		if err != nil {
+			return steps, err
+		}
+		steps = append(steps, step)
+	}
+}
+
+func (suite *RecorderTestSuite) TestRoundTrip() {
+	// Adapt
+	m := createTraceMem(program)
+
+	// Act
+	_, data := record(m, 8, 0)
+	steps, err := readAll(data)
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 8, len(steps), "Every step recorded")
+	assert.Equal(suite.T(), "0000000000 0x0200 7001 ADD V0, 0x01     V0=0x01", steps[0].String())
+	assert.Equal(suite.T(), "0000000001 0x0202 A300 LD I, 0x300      I=0x0300", steps[1].String())
+	assert.Equal(suite.T(), "0000000002 0x0204 F055 LD [I], V0       I=0x0301 [0x0300]=01", steps[2].String())
+	assert.Equal(suite.T(), "0000000003 0x0206 1200 JP 0x200", steps[3].String())
+	assert.Equal(suite.T(), "0000000006 0x0204 F055 LD [I], V0       I=0x0301 [0x0300]=02", steps[6].String())
+}
+
+func (suite *RecorderTestSuite) TestStartCycle() {
+	// Adapt
+	m := createTraceMem(program)
+	for i := 0; i < 300; i++ {
+		m.Iterate()
+	}
+
+	// Act
+	_, data := record(m, 1, 0)
+	steps, _ := readAll(data)
+
+	// Assert
+	assert.Equal(suite.T(), uint64(300), steps[0].Cycle, "Cycles counted from the start of the trace")
+}
+
+func (suite *RecorderTestSuite) TestCycleJumps() {
+	// Adapt
+	m := createTraceMem(program)
+	var buf bytes.Buffer
+	r := NewRecorder(&buf, 0)
+	r.Step(m, m.Iterate)
+	r.Step(m, m.Iterate)
+
+	// Act
+	m.Cycles = 500
+	r.Step(m, m.Iterate)
+	m.Cycles = 1
+	r.Step(m, m.Iterate)
+	r.Step(m, m.Iterate)
+	r.Flush()
+	steps, err := readAll(buf.Bytes())
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	var cycles []uint64
+	for _, step := range steps {
+		cycles = append(cycles, step.Cycle)
+	}
+	assert.Equal(suite.T(), []uint64{0, 1, 500, 1, 2}, cycles, "Cycles kept after a rewind or a load")
+}
+
+func (suite *RecorderTestSuite) TestLongI() {
+	// Adapt
+	m := chip8.NewSeededMemory(chip8.QuirksXOCHIP, 1)
+	copy(m.Memory[0x200:], []byte{0xF0, 0x00, 0x12, 0x34, 0x70, 0x01})
+
+	// Act
+	_, data := record(m, 2, 0)
+	steps, err := readAll(data)
+
+	// Assert
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, len(steps), "Every step recorded")
+	assert.Equal(suite.T(), "0000000000 0x0200 F000 1234 LD I, 0x1234     I=0x1234", steps[0].String())
+	assert.Equal(suite.T(), "0000000001 0x0204 7001 ADD V0, 0x01     V0=0x01", steps[1].String())
+}
+
+func (suite *RecorderTestSuite) TestMaxSize() {
+	// Adapt
+	m := createTraceMem(program)
+
+	// Act
+	r, data := record(m, 100, 64)
+	steps, err := readAll(data)
+
+	// Assert
+	assert.Nil(suite.T(), err, "No truncated step")
+	assert.True(suite.T(), len(data) <= 64, "Size capped")
+	assert.Equal(suite.T(), uint64(100-len(steps)), r.Dropped, "Dropped steps counted")
+}
+
+func (suite *RecorderTestSuite) TestNotExecuted() {
+	// Adapt
+	m := createTraceMem("DB 0xFF, 0xFF")
+
+	// Act
+	_, data := record(m, 1, 0)
+
+	// Assert
+	assert.Empty(suite.T(), data, "Invalid instruction not recorded")
+}
+
+func (suite *RecorderTestSuite) TestChainsWatcher() {
+	// Adapt
+	m := createTraceMem(program)
+	w := &countWatcher{}
+	m.Watcher = w
+
+	// Act
+	record(m, 3, 0)
+
+	// Assert
+	assert.Equal(suite.T(), 1, w.writes, "Access forwarded")
+	assert.Equal(suite.T(), w, m.Watcher, "Watcher restored")
+}
+
+func (suite *RecorderTestSuite) TestReader_NotATrace() {
+	// Act
+	_, err := readAll([]byte("C8T"))
+	_, err2 := readAll([]byte("XXXX\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+
+	// Assert
+	assert.Equal(suite.T(), ErrNotATrace, err)
+	assert.Equal(suite.T(), ErrNotATrace, err2)
+}
+
+func (suite *RecorderTestSuite) TestReader_Truncated() {
+	// Adapt
+	m := createTraceMem(program)
+	_, data := record(m, 3, 0)
+
+	// Act
+	steps, err := readAll(data[:len(data)-1])
+
+	// Assert
+	assert.NotNil(suite.T(), err)
+	assert.Equal(suite.T(), 2, len(steps), "Steps before the truncated one read")
+}
+
+// countWatcher counts the memory writes
+type countWatcher struct {
+	writes int
+}
+
+func (w *countWatcher) Access(address uint16, size uint16, write bool) {
+	if write {
+		w.writes++
+	}
+}
+
+func TestRecorderTestSuite(t *testing.T) {
+	suite.Run(t, new(RecorderTestSuite))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Oicho/GO-Chip8/trace"
+)
+
+// traceCommand prints the steps of a trace selected by the filters
+func traceCommand(args []string) error {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	from := flags.String("from", "", "print the steps at this address or after")
+	to := flags.String("to", "", "print the steps at this address or before")
+	opcode := flags.String("opcode", "", "print the steps matching an opcode pattern like 8XY4 or DXYN")
+	reg := flags.String("reg", "", "print the steps changing one of these registers, like V0,I")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: program trace [options] filepath")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var filter trace.Filter
+	var err error
+	if *from != "" || *to != "" {
+		filter.To = 0xFFFF
+	}
+	if *from != "" {
+		if filter.From, err = parseAddress(*from); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if filter.To, err = parseAddress(*to); err != nil {
+			return err
+		}
+	}
+	if *opcode != "" {
+		if filter.Opcode, err = trace.ParsePattern(*opcode); err != nil {
+			return err
+		}
+	}
+	if *reg != "" {
+		filter.Registers = strings.Split(*reg, ",")
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r, err := trace.NewReader(file)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for {
+		step, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if filter.Match(step) {
+			fmt.Fprintln(out, step)
+		}
+	}
+}
+
+// parseAddress parses a decimal or 0x hexadecimal address
+func parseAddress(s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %s", s)
+	}
+	return uint16(n), nil
+}